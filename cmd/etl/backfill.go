@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// backfillOptions holds the arguments of the backfill command
+type backfillOptions struct {
+	From  time.Time
+	To    time.Time // exclusive
+	Chunk string
+}
+
+// parseBackfillArgs parses `backfill -from YYYY-MM-DD [-to YYYY-MM-DD] [-chunk month]`.
+// The -to date is inclusive on the command line and defaults to today.
+func parseBackfillArgs(args []string, now time.Time) (backfillOptions, error) {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	from := fs.String("from", "", "First day to backfill (YYYY-MM-DD, required)")
+	to := fs.String("to", "", "Last day to backfill (YYYY-MM-DD, defaults to today)")
+	chunk := fs.String("chunk", "month", "Chunk size: day, week, month, or year")
+	if err := fs.Parse(args); err != nil {
+		return backfillOptions{}, fmt.Errorf("usage: backfill -from YYYY-MM-DD [-to YYYY-MM-DD] [-chunk day|week|month|year]: %w", err)
+	}
+
+	if *from == "" {
+		return backfillOptions{}, fmt.Errorf("usage: backfill -from YYYY-MM-DD [-to YYYY-MM-DD] [-chunk day|week|month|year]")
+	}
+
+	opts := backfillOptions{Chunk: *chunk}
+
+	var err error
+	if opts.From, err = time.Parse("2006-01-02", *from); err != nil {
+		return backfillOptions{}, fmt.Errorf("invalid -from date: %s", *from)
+	}
+
+	last := now.UTC()
+	if *to != "" {
+		if last, err = time.Parse("2006-01-02", *to); err != nil {
+			return backfillOptions{}, fmt.Errorf("invalid -to date: %s", *to)
+		}
+	}
+	opts.To = time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	if !opts.From.Before(opts.To) {
+		return backfillOptions{}, fmt.Errorf("-from %s is after -to %s", *from, last.Format("2006-01-02"))
+	}
+
+	return opts, nil
+}
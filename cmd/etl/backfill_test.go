@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBackfillArgs(t *testing.T) {
+	now := time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantFrom  string
+		wantTo    string
+		wantChunk string
+		wantErr   bool
+	}{
+		{"defaults", []string{"-from", "2024-01-01"}, "2024-01-01", "2024-03-16", "month", false},
+		{"explicit range", []string{"-from", "2020-01-01", "-to", "2020-12-31", "-chunk", "week"}, "2020-01-01", "2021-01-01", "week", false},
+		{"single day", []string{"-from", "2024-01-01", "-to", "2024-01-01"}, "2024-01-01", "2024-01-02", "month", false},
+		{"missing from", []string{"-to", "2024-01-01"}, "", "", "", true},
+		{"invalid from", []string{"-from", "01/01/2024"}, "", "", "", true},
+		{"invalid to", []string{"-from", "2024-01-01", "-to", "soon"}, "", "", "", true},
+		{"from after to", []string{"-from", "2024-02-01", "-to", "2024-01-01"}, "", "", "", true},
+		{"unknown flag", []string{"-from", "2024-01-01", "-bogus"}, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseBackfillArgs(tt.args, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseBackfillArgs(%v) expected error", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBackfillArgs(%v) error = %v", tt.args, err)
+			}
+			if got := opts.From.Format("2006-01-02"); got != tt.wantFrom {
+				t.Errorf("From = %s, want %s", got, tt.wantFrom)
+			}
+			if got := opts.To.Format("2006-01-02"); got != tt.wantTo {
+				t.Errorf("To = %s, want %s", got, tt.wantTo)
+			}
+			if opts.Chunk != tt.wantChunk {
+				t.Errorf("Chunk = %s, want %s", opts.Chunk, tt.wantChunk)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/export"
+	"nasa-data-hub-etl/pkg/models"
+)
+
+const exportUsage = "usage: export -format geojson [-output FILE] [-latest] [-category ID] [-status open|closed] [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-bbox min_lon,min_lat,max_lon,max_lat]"
+
+// exportOptions holds the arguments of the export command
+type exportOptions struct {
+	Format string
+	Output string // Standard output if empty
+	Filter database.EventFilter
+	Export export.Options
+}
+
+// parseExportArgs parses the export command line. The -to date is inclusive.
+func parseExportArgs(args []string) (exportOptions, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "geojson", "Output format, only geojson is supported")
+	output := fs.String("output", "", "File to write, standard output if empty")
+	latest := fs.Bool("latest", false, "Export only the latest position of each event")
+	category := fs.String("category", "", "Only events of this category")
+	status := fs.String("status", "", "Only open or closed events")
+	from := fs.String("from", "", "Only observations from this day on (YYYY-MM-DD)")
+	to := fs.String("to", "", "Only observations until this day (YYYY-MM-DD)")
+	bbox := fs.String("bbox", "", "Only observations within min_lon,min_lat,max_lon,max_lat")
+	if err := fs.Parse(args); err != nil {
+		return exportOptions{}, fmt.Errorf("%s: %w", exportUsage, err)
+	}
+
+	if *format != "geojson" {
+		return exportOptions{}, fmt.Errorf("unsupported export format: %s", *format)
+	}
+
+	opts := exportOptions{
+		Format: *format,
+		Output: *output,
+		Filter: database.EventFilter{CategoryID: *category},
+		Export: export.Options{LatestOnly: *latest},
+	}
+
+	switch *status {
+	case "", database.EventStatusOpen, database.EventStatusClosed:
+		opts.Filter.Status = *status
+	default:
+		return exportOptions{}, fmt.Errorf("invalid -status: %s", *status)
+	}
+
+	if *from != "" {
+		day, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return exportOptions{}, fmt.Errorf("invalid -from date: %s", *from)
+		}
+		opts.Filter.From = &day
+	}
+
+	if *to != "" {
+		day, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return exportOptions{}, fmt.Errorf("invalid -to date: %s", *to)
+		}
+		end := day.AddDate(0, 0, 1)
+		opts.Filter.To = &end
+	}
+
+	if opts.Filter.From != nil && opts.Filter.To != nil && !opts.Filter.From.Before(*opts.Filter.To) {
+		return exportOptions{}, fmt.Errorf("-from %s is after -to %s", *from, *to)
+	}
+
+	if *bbox != "" {
+		box, err := models.ParseBBox(*bbox)
+		if err != nil {
+			return exportOptions{}, fmt.Errorf("invalid -bbox: %w", err)
+		}
+		opts.Filter.BBox = &box
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	opts, err := parseExportArgs([]string{"-format", "geojson", "-latest", "-category", "wildfires", "-status", "open",
+		"-from", "2024-07-01", "-to", "2024-07-31", "-bbox", "-125,32,-114,42", "-output", "events.geojson"})
+	if err != nil {
+		t.Fatalf("parseExportArgs() error = %v", err)
+	}
+	if !opts.Export.LatestOnly || opts.Output != "events.geojson" || opts.Filter.CategoryID != "wildfires" || opts.Filter.Status != "open" {
+		t.Errorf("parseExportArgs() = %+v", opts)
+	}
+	if got := opts.Filter.To.Format("2006-01-02"); got != "2024-08-01" {
+		t.Errorf("Filter.To = %s, want the day after -to", got)
+	}
+	if opts.Filter.BBox == nil || opts.Filter.BBox.MinLon != -125 || opts.Filter.BBox.MaxLat != 42 {
+		t.Errorf("Filter.BBox = %+v", opts.Filter.BBox)
+	}
+
+	for _, args := range [][]string{
+		{"-format", "csv"},
+		{"-status", "pending"},
+		{"-from", "07/01/2024"},
+		{"-from", "2024-08-01", "-to", "2024-07-01"},
+		{"-bbox", "1,2,3"},
+		{"-bogus"},
+	} {
+		if _, err := parseExportArgs(args); err == nil {
+			t.Errorf("parseExportArgs(%v) expected error", args)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,20 +15,32 @@ import (
 	"nasa-data-hub-etl/internal/config"
 	"nasa-data-hub-etl/internal/database"
 	"nasa-data-hub-etl/internal/etl"
+	"nasa-data-hub-etl/internal/export"
 	"nasa-data-hub-etl/internal/logger"
 	"nasa-data-hub-etl/internal/server"
+	"nasa-data-hub-etl/internal/tracing"
+	"nasa-data-hub-etl/internal/version"
 )
 
 func main() {
 	// Parse command line flags
 	var (
 		healthCheck = flag.Bool("health", false, "Run health check and exit")
-		dbInitMode  = flag.String("db-init", "Auto", "Database initialization mode: Create, Revive, or Auto")
+		dbInitMode  = flag.String("db-init", "Auto", "Database initialization mode: Create, Revive, Auto, or Migrate")
+		schedule    = flag.Bool("schedule", false, "Run the ETL pipeline every etl.interval until shutdown")
+		fullRefresh = flag.Bool("full-refresh", false, "Reset the events watermark before running")
+		showVersion = flag.Bool("version", false, "Print version information and exit")
 	)
 	flag.Parse()
 
+	if *showVersion {
+		fmt.Println(version.Get())
+		return
+	}
+
 	// Initialize logger
 	log := logger.New()
+	log.WithField("version", version.Get().String()).Info("Starting NASA Data Hub ETL")
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -33,6 +48,88 @@ func main() {
 		log.WithError(err).Fatal("Failed to load configuration")
 	}
 
+	// Switch to the configured level, format and log file
+	closeLog, err := logger.Configure(log, cfg.Log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure logging")
+	}
+	defer closeLog()
+
+	// Trace runs with the configured exporter, a no-op with none
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			log.WithError(err).Warn("Failed to flush traces")
+		}
+	}()
+
+	// Handle the migrate subcommand
+	if flag.Arg(0) == "migrate" {
+		db, err := database.Open(&cfg.Database, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to connect to database")
+		}
+		defer db.Close()
+
+		migratable, ok := db.(database.Migratable)
+		if !ok {
+			log.WithField("driver", cfg.Database.Driver).Fatal("Database driver has no schema migrations")
+		}
+
+		migrator, err := migratable.Migrator()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load migrations")
+		}
+
+		if err := runMigrateCommand(context.Background(), migrator, flag.Args()[1:], os.Stdout); err != nil {
+			log.WithError(err).Fatal("Migration command failed")
+		}
+		return
+	}
+
+	// Handle the export subcommand, it only reads what is already loaded
+	if flag.Arg(0) == "export" {
+		opts, err := parseExportArgs(flag.Args()[1:])
+		if err != nil {
+			log.WithError(err).Fatal("Invalid export arguments")
+		}
+
+		db, err := database.Open(&cfg.Database, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to connect to database")
+		}
+		defer db.Close()
+
+		out := os.Stdout
+		if opts.Output != "" {
+			if out, err = os.Create(opts.Output); err != nil {
+				log.WithError(err).Fatal("Failed to create export file")
+			}
+			defer out.Close()
+		} else {
+			// Keep the logs out of the exported document
+			log.SetOutput(os.Stderr)
+		}
+
+		w := bufio.NewWriter(out)
+		features, err := export.WriteGeoJSON(context.Background(), db, opts.Filter, w, opts.Export)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.WithError(err).Fatal("Export failed")
+		}
+
+		log.WithField("features", features).Info("Export complete")
+		return
+	}
+
 	// Create ETL pipeline
 	pipeline, err := etl.NewPipeline(cfg, log)
 	if err != nil {
@@ -46,13 +143,25 @@ func main() {
 		log.WithError(err).Fatal("Invalid database initialization mode")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
 	defer cancel()
 
 	if err := pipeline.InitializeDatabase(ctx, initMode); err != nil {
 		log.WithError(err).Fatal("Failed to initialize database structure")
 	}
 
+	// Migrate mode only applies pending migrations
+	if initMode == database.InitModeMigrate {
+		log.Info("Database migrations complete")
+		return
+	}
+
+	if *fullRefresh {
+		if err := pipeline.ResetWatermark(ctx); err != nil {
+			log.WithError(err).Fatal("Failed to reset watermark")
+		}
+	}
+
 	// Handle health check flag
 	if *healthCheck {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -67,6 +176,12 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Runs left 'running' by a killed process would otherwise be reported as
+	// the latest state forever
+	if _, err := pipeline.RecoverStaleRuns(ctx); err != nil {
+		log.WithError(err).Error("Failed to recover stale ETL runs")
+	}
+
 	// Setup graceful shutdown
 	cancel()
 	ctx, cancel = context.WithCancel(context.Background())
@@ -90,10 +205,60 @@ func main() {
 		}
 	}()
 
+	// With several replicas only the holder of the leader lease runs the
+	// pipeline, the others stay on standby. Stopping releases the lease.
+	stopElection := pipeline.StartLeaderElection(ctx)
+	defer stopElection()
+
+	// Fatal exits without running deferred calls, release the lease first so
+	// that a standby need not wait for it to expire
+	fatal := func(err error, msg string) {
+		stopElection()
+		if err != nil {
+			log.WithError(err).Fatal(msg)
+		}
+		log.Fatal(msg)
+	}
+
+	// A backfill holds the lease and the run claim of the pipeline, so it
+	// does not load alongside the leader and triggered runs are rejected
+	if flag.Arg(0) == "backfill" {
+		opts, err := parseBackfillArgs(flag.Args()[1:], time.Now())
+		if err != nil {
+			fatal(err, "Invalid backfill arguments")
+		}
+
+		err = pipeline.Backfill(ctx, opts.From, opts.To, opts.Chunk)
+		if errors.Is(err, etl.ErrNotLeader) {
+			fatal(nil, "Another instance holds the leader lease, stop it or wait for it to release the lease before starting a backfill")
+		}
+		if err != nil {
+			fatal(err, "Backfill failed")
+		}
+		return
+	}
+
+	// Run the pipeline on a schedule until a shutdown signal arrives
+	if *schedule {
+		log.WithField("interval", cfg.ETL.Interval.String()).Info("Starting NASA Data Hub ETL scheduler")
+		scheduler := etl.NewScheduler(pipeline, cfg.ETL.Interval, log)
+		if err := scheduler.Start(ctx); err != nil {
+			fatal(err, "ETL scheduler failed")
+		}
+
+		log.Info("ETL scheduler stopped")
+		return
+	}
+
 	// Start ETL pipeline
 	log.Info("Starting NASA Data Hub ETL pipeline")
-	if err := pipeline.Run(ctx); err != nil {
-		log.WithError(err).Fatal("ETL pipeline failed")
+	err = pipeline.Run(ctx)
+	if errors.Is(err, etl.ErrNotLeader) {
+		log.Info("Another instance holds the leader lease, skipping ETL run")
+		return
+	}
+	if err != nil {
+		fatal(err, "ETL pipeline failed")
 	}
 
 	log.Info("ETL pipeline completed successfully")
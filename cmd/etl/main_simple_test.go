@@ -21,11 +21,11 @@ func (m *MockDatabase) InitializeDatabase(ctx context.Context, mode database.Ini
 	return nil
 }
 
-func (m *MockDatabase) StartETLRun(ctx context.Context) (int64, error) {
+func (m *MockDatabase) StartETLRun(ctx context.Context, run database.ETLRunStart) (int64, error) {
 	return 0, nil
 }
 
-func (m *MockDatabase) CompleteETLRun(ctx context.Context, runID int64, status string, eventsProcessed, categoriesProcessed int, errorMsg *string) error {
+func (m *MockDatabase) CompleteETLRun(ctx context.Context, runID int64, result database.ETLRunResult) error {
 	return nil
 }
 
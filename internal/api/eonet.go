@@ -3,15 +3,23 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"path"
+	"strconv"
 	"time"
 
 	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/metrics"
+	"nasa-data-hub-etl/internal/tracing"
 	"nasa-data-hub-etl/pkg/models"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 // EONETClient handles communication with NASA EONET API
@@ -19,116 +27,62 @@ type EONETClient struct {
 	config     *config.NASAConfig
 	httpClient *http.Client
 	logger     *logrus.Logger
+	retry      RetryPolicy
+	timeout    time.Duration // A request fails if the API sends nothing for this long
 }
 
 // NewEONETClient creates a new EONET API client
 func NewEONETClient(cfg *config.NASAConfig, logger *logrus.Logger) *EONETClient {
 	return &EONETClient{
-		config: cfg,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		config:     cfg,
+		httpClient: &http.Client{},
+		logger:     logger,
+		timeout:    30 * time.Second,
 	}
 }
 
-// FetchEventsOptions represents options for fetching events
-type FetchEventsOptions struct {
-	Days       int    `json:"days,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
-	Status     string `json:"status,omitempty"` // "open", "closed", "all"
-	CategoryID int    `json:"category,omitempty"`
-	SourceID   string `json:"source,omitempty"`
+// log returns the logger with the correlation fields carried by ctx
+func (c *EONETClient) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, c.logger)
 }
 
-// FetchEvents fetches events from NASA EONET API
-func (c *EONETClient) FetchEvents(ctx context.Context, opts FetchEventsOptions) (*models.EONETResponse, error) {
-	url := c.buildEventsURL(opts)
-
-	c.logger.WithFields(logrus.Fields{
-		"url":  url,
-		"opts": opts,
-	}).Debug("Fetching events from NASA EONET API")
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Add API key if provided
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-	}
-
-	req.Header.Set("User-Agent", "NASA-Data-Hub-ETL/1.0")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var eonetResponse models.EONETResponse
-	if err := json.Unmarshal(body, &eonetResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	c.logger.WithFields(logrus.Fields{
-		"events_count":     len(eonetResponse.Events),
-		"categories_count": len(eonetResponse.Categories),
-	}).Info("Successfully fetched events from NASA EONET API")
+// SetRetryPolicy sets how transient API failures are retried. By default requests are not retried.
+func (c *EONETClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
 
-	return &eonetResponse, nil
+// FetchEventsOptions represents options for fetching events
+type FetchEventsOptions struct {
+	Days       int       `json:"days,omitempty"`
+	Start      time.Time `json:"start,omitempty"` // Only events active on or after this date
+	End        time.Time `json:"end,omitempty"`   // Only events active on or before this date
+	Limit      int       `json:"limit,omitempty"`
+	Status     string    `json:"status,omitempty"`   // "open", "closed", "all"
+	CategoryID string    `json:"category,omitempty"` // e.g. "wildfires"
+	SourceID   string    `json:"source,omitempty"`
+	MagID      string    `json:"magID,omitempty"`  // Magnitude type, e.g. "mag_kts"
+	MagMin     *float64  `json:"magMin,omitempty"` // Minimum magnitude value, requires MagID
+	MagMax     *float64  `json:"magMax,omitempty"` // Maximum magnitude value, requires MagID
 }
 
 // FetchCategories fetches categories from NASA EONET API
-func (c *EONETClient) FetchCategories(ctx context.Context) ([]models.Category, error) {
-	url := fmt.Sprintf("%s/categories", c.config.APIURL)
-
-	c.logger.WithField("url", url).Debug("Fetching categories from NASA EONET API")
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+func (c *EONETClient) FetchCategories(ctx context.Context) (categories []models.Category, err error) {
+	ctx, span := tracing.Start(ctx, "eonet.fetch_categories")
+	defer func() {
+		span.SetAttributes(attribute.Int("etl.records", len(categories)))
+		tracing.End(span, err)
+	}()
 
-	// Add API key if provided
-	if c.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-	}
-
-	req.Header.Set("User-Agent", "NASA-Data-Hub-ETL/1.0")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
+	url := fmt.Sprintf("%s/categories", c.config.APIURL)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	c.log(ctx).WithField("url", url).Debug("Fetching categories from NASA EONET API")
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.get(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	// Try to unmarshal as direct array first
-	var categories []models.Category
 	if err := json.Unmarshal(body, &categories); err != nil {
 		// If that fails, try to unmarshal as object with categories field
 		var response struct {
@@ -140,7 +94,7 @@ func (c *EONETClient) FetchCategories(ctx context.Context) ([]models.Category, e
 		categories = response.Categories
 	}
 
-	c.logger.WithField("categories_count", len(categories)).Info("Successfully fetched categories from NASA EONET API")
+	c.log(ctx).WithField("categories_count", len(categories)).Info("Successfully fetched categories from NASA EONET API")
 
 	return categories, nil
 }
@@ -155,6 +109,14 @@ func (c *EONETClient) buildEventsURL(opts FetchEventsOptions) string {
 		params = append(params, fmt.Sprintf("days=%d", opts.Days))
 	}
 
+	if !opts.Start.IsZero() {
+		params = append(params, fmt.Sprintf("start=%s", opts.Start.UTC().Format("2006-01-02")))
+	}
+
+	if !opts.End.IsZero() {
+		params = append(params, fmt.Sprintf("end=%s", opts.End.UTC().Format("2006-01-02")))
+	}
+
 	if opts.Limit > 0 {
 		params = append(params, fmt.Sprintf("limit=%d", opts.Limit))
 	}
@@ -163,14 +125,26 @@ func (c *EONETClient) buildEventsURL(opts FetchEventsOptions) string {
 		params = append(params, fmt.Sprintf("status=%s", opts.Status))
 	}
 
-	if opts.CategoryID > 0 {
-		params = append(params, fmt.Sprintf("category=%d", opts.CategoryID))
+	if opts.CategoryID != "" {
+		params = append(params, fmt.Sprintf("category=%s", opts.CategoryID))
 	}
 
 	if opts.SourceID != "" {
 		params = append(params, fmt.Sprintf("source=%s", opts.SourceID))
 	}
 
+	if opts.MagID != "" {
+		params = append(params, fmt.Sprintf("magID=%s", opts.MagID))
+	}
+
+	if opts.MagMin != nil {
+		params = append(params, fmt.Sprintf("magMin=%s", strconv.FormatFloat(*opts.MagMin, 'f', -1, 64)))
+	}
+
+	if opts.MagMax != nil {
+		params = append(params, fmt.Sprintf("magMax=%s", strconv.FormatFloat(*opts.MagMax, 'f', -1, 64)))
+	}
+
 	if len(params) > 0 {
 		url += "?" + params[0]
 		for i := 1; i < len(params); i++ {
@@ -185,23 +159,162 @@ func (c *EONETClient) buildEventsURL(opts FetchEventsOptions) string {
 func (c *EONETClient) HealthCheck(ctx context.Context) error {
 	url := fmt.Sprintf("%s/categories", c.config.APIURL)
 
+	if _, err := c.get(ctx, url); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	return nil
+}
+
+// get performs a GET request, retrying transient failures according to the
+// client's retry policy, and returns the response body
+func (c *EONETClient) get(ctx context.Context, url string) ([]byte, error) {
+	var body []byte
+	err := c.request(ctx, url, func(r io.Reader) (err error) {
+		body, err = io.ReadAll(r)
+		return err
+	})
+	return body, err
+}
+
+// request performs a GET request and passes the body of a successful response
+// to read. Failed attempts, including failures of read, are retried according
+// to the client's retry policy unless read marks them with permanentError.
+func (c *EONETClient) request(ctx context.Context, url string, read func(io.Reader) error) error {
+	maxAttempts := c.retry.MaxRetries + 1
+	causes := make([]error, 0, maxAttempts)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		entry := c.log(ctx).WithFields(logrus.Fields{
+			"url":          url,
+			"attempt":      attempt,
+			"max_attempts": maxAttempts,
+		})
+		entry.Debug("Sending request to NASA EONET API")
+
+		err := c.doGet(ctx, url, read)
+		if err == nil {
+			return nil
+		}
+		causes = append(causes, fmt.Errorf("attempt %d: %w", attempt, err))
+
+		if attempt == maxAttempts || !isRetryable(ctx, err) {
+			entry.WithError(err).Warn("NASA EONET API request failed")
+			break
+		}
+
+		delay := c.retry.backoff(attempt, err)
+		entry.WithError(err).WithField("retry_in", delay.String()).Warn("NASA EONET API request failed, retrying")
+
+		if err := sleepContext(ctx, delay); err != nil {
+			causes = append(causes, err)
+			break
+		}
+	}
+
+	return &RetryError{Attempts: causes}
+}
+
+// maxErrorBody bounds the part of an error response kept in StatusError
+const maxErrorBody = 64 << 10
+
+// errStalled is the cause of requests cancelled because the API sent nothing
+// for longer than the client timeout
+var errStalled = errors.New("NASA EONET API stopped sending data")
+
+// doGet performs a single GET request and passes the response body to read.
+// Rather than bounding the whole request, which would include the time read
+// spends on a streamed body, the request fails if the API sends nothing for
+// the client timeout.
+func (c *EONETClient) doGet(ctx context.Context, url string, read func(io.Reader) error) (err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stall := time.AfterFunc(c.timeout, func() {
+		cancel(fmt.Errorf("%w for %s", errStalled, c.timeout))
+	})
+	defer stall.Stop()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add API key if provided
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
 	}
 
 	req.Header.Set("User-Agent", "NASA-Data-Hub-ETL/1.0")
 	req.Header.Set("Accept", "application/json")
 
+	stats := requestStatsFrom(ctx)
+	stats.addRequest()
+
+	// Endpoints are the last path element, e.g. events or categories
+	endpoint := path.Base(req.URL.Path)
+	code := "error"
+	body := &stallReader{timer: stall, timeout: c.timeout}
+
+	ctx, span := tracing.StartClient(ctx, "GET "+endpoint, semconv.HTTPRequestMethodGet, semconv.URLFull(url))
+	req = req.WithContext(ctx)
+	defer func(start time.Time) {
+		stats.addBytes(body.n)
+		metrics.APIRequests.WithLabelValues(endpoint, code).Inc()
+		metrics.APILatency.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		span.SetAttributes(semconv.HTTPResponseBodySize(body.n))
+		tracing.End(span, err)
+	}(time.Now())
+
+	// Report a stall rather than the cancellation it caused
+	stalled := func(err error) error {
+		if cause := context.Cause(ctx); errors.Is(cause, errStalled) {
+			return fmt.Errorf("%w: %w", cause, err)
+		}
+		return err
+	}
+
 	resp, err := c.httpClient.Do(req)
+	stall.Stop()
 	if err != nil {
-		return fmt.Errorf("health check failed: %w", err)
+		return fmt.Errorf("failed to make request: %w", stalled(err))
 	}
 	defer resp.Body.Close()
 
+	code = strconv.Itoa(resp.StatusCode)
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	body.r = resp.Body
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("health check failed with status %d", resp.StatusCode)
+		data, _ := io.ReadAll(io.LimitReader(body, maxErrorBody))
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(data),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if err := read(body); err != nil {
+		return fmt.Errorf("failed to read response body: %w", stalled(err))
 	}
 
 	return nil
 }
+
+// stallReader reads a response body and counts the bytes read. The stall
+// timer of the request only runs while a read is in progress, so the time the
+// caller spends between reads does not count.
+type stallReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+	n       int
+}
+
+// Read implements io.Reader
+func (s *stallReader) Read(p []byte) (int, error) {
+	s.timer.Reset(s.timeout)
+	n, err := s.r.Read(p)
+	s.timer.Stop()
+	s.n += n
+	return n, err
+}
@@ -170,3 +170,48 @@ func TestEONETClient_ContextCancellation(t *testing.T) {
 		t.Error("FetchCategories() should have returned error due to context cancellation")
 	}
 }
+
+func TestEONETClient_BuildEventsURL(t *testing.T) {
+	client := NewEONETClient(&config.NASAConfig{APIURL: "https://eonet.example/api/v3"}, logrus.New())
+	magMin, magMax := 64.0, 137.5
+
+	tests := []struct {
+		name string
+		opts FetchEventsOptions
+		want string
+	}{
+		{
+			name: "no options",
+			opts: FetchEventsOptions{},
+			want: "https://eonet.example/api/v3/events",
+		},
+		{
+			name: "date window",
+			opts: FetchEventsOptions{
+				Start:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:    time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+				Limit:  500,
+				Status: "all",
+			},
+			want: "https://eonet.example/api/v3/events?start=2020-01-01&end=2020-01-31&limit=500&status=all",
+		},
+		{
+			name: "category",
+			opts: FetchEventsOptions{Days: 7, CategoryID: "wildfires"},
+			want: "https://eonet.example/api/v3/events?days=7&category=wildfires",
+		},
+		{
+			name: "magnitude",
+			opts: FetchEventsOptions{CategoryID: "severeStorms", MagID: "mag_kts", MagMin: &magMin, MagMax: &magMax},
+			want: "https://eonet.example/api/v3/events?category=severeStorms&magID=mag_kts&magMin=64&magMax=137.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.buildEventsURL(tt.opts); got != tt.want {
+				t.Errorf("buildEventsURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxRetryDelay caps the exponential backoff when RetryPolicy.MaxDelay is unset
+const defaultMaxRetryDelay = 5 * time.Minute
+
+// RetryPolicy controls how EONETClient retries transient failures
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt, 0 disables retrying
+	BaseDelay  time.Duration // Delay before the first retry, doubled on every further retry
+	MaxDelay   time.Duration // Upper bound for the backoff delay and for Retry-After hints
+}
+
+// backoff returns the delay before the retry following the given attempt.
+// A Retry-After hint from the server takes precedence over the computed delay
+// but is capped at the maximum delay, so that a far-off hint does not block
+// the run for hours.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxRetryDelay
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxDelay)
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Equal jitter: keep half of the delay and randomize the other half so that
+	// replicas failing at the same time don't retry in lockstep
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// StatusError is returned when the API responds with a non-200 status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the status code indicates a transient failure
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryError is returned when a request failed on every attempt. It carries
+// the cause of each attempt in order.
+type RetryError struct {
+	Attempts []error
+}
+
+// Error implements the error interface
+func (e *RetryError) Error() string {
+	causes := make([]string, 0, len(e.Attempts))
+	for _, err := range e.Attempts {
+		causes = append(causes, err.Error())
+	}
+	return fmt.Sprintf("request failed after %d attempt(s): %s", len(e.Attempts), strings.Join(causes, "; "))
+}
+
+// Unwrap exposes every attempt's cause to errors.Is and errors.As
+func (e *RetryError) Unwrap() []error {
+	return e.Attempts
+}
+
+// permanentError marks a failure that must not be retried, e.g. because part
+// of a streamed response was already passed on
+type permanentError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the marked error
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// isRetryable reports whether a failed attempt is worth retrying
+func isRetryable(ctx context.Context, err error) bool {
+	// The caller gave up, retrying would only fail again
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+
+	// Connection errors, timeouts and truncated bodies
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
+
+// sleepContext waits for the given duration or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/metrics"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func newRetryTestClient(url string, maxRetries int) *EONETClient {
+	client := NewEONETClient(&config.NASAConfig{APIURL: url}, logrus.New())
+	client.SetRetryPolicy(RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   5 * time.Millisecond,
+	})
+	return client
+}
+
+func TestEONETClient_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+	}{
+		{name: "too many requests", statusCode: http.StatusTooManyRequests},
+		{name: "server error", statusCode: http.StatusInternalServerError},
+		{name: "service unavailable", statusCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) < 3 {
+					w.WriteHeader(tt.statusCode)
+					return
+				}
+				// nolint:errcheck // Ignore error in test
+				_, _ = w.Write([]byte(`[{"id":"wildfires","title":"Wildfires"}]`))
+			}))
+			defer server.Close()
+
+			client := newRetryTestClient(server.URL, 3)
+			categories, err := client.FetchCategories(context.Background())
+			if err != nil {
+				t.Fatalf("FetchCategories() error = %v", err)
+			}
+
+			if len(categories) != 1 {
+				t.Errorf("FetchCategories() returned %d categories, want 1", len(categories))
+			}
+			if got := calls.Load(); got != 3 {
+				t.Errorf("server received %d requests, want 3", got)
+			}
+		})
+	}
+}
+
+func TestEONETClient_RecordsRequestMetrics(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// nolint:errcheck // Ignore error in test
+		_, _ = w.Write([]byte(`[{"id":"wildfires","title":"Wildfires"}]`))
+	}))
+	defer server.Close()
+
+	unavailable := metrics.APIRequests.WithLabelValues("categories", "503")
+	ok := metrics.APIRequests.WithLabelValues("categories", "200")
+	before := [2]float64{testutil.ToFloat64(unavailable), testutil.ToFloat64(ok)}
+
+	client := newRetryTestClient(server.URL, 1)
+	if _, err := client.FetchCategories(context.Background()); err != nil {
+		t.Fatalf("FetchCategories() error = %v", err)
+	}
+
+	// Every attempt counts, not only the final one
+	if got := testutil.ToFloat64(unavailable) - before[0]; got != 1 {
+		t.Errorf("requests with status 503 = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(ok) - before[1]; got != 1 {
+		t.Errorf("requests with status 200 = %v, want 1", got)
+	}
+}
+
+func TestEONETClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL, 3)
+	_, err := client.StreamEvents(context.Background(), FetchEventsOptions{}, func(models.Event) error { return nil })
+	if err == nil {
+		t.Fatal("StreamEvents() should fail on 404")
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("StreamEvents() error = %v, want StatusError with status 404", err)
+	}
+}
+
+func TestEONETClient_ErrorCarriesEveryAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL, 2)
+	err := client.HealthCheck(context.Background())
+	if err == nil {
+		t.Fatal("HealthCheck() should fail")
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("HealthCheck() error = %v, want RetryError", err)
+	}
+	if len(retryErr.Attempts) != 3 {
+		t.Errorf("RetryError has %d attempts, want 3", len(retryErr.Attempts))
+	}
+}
+
+func TestEONETClient_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var firstCall time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			firstCall = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		// nolint:errcheck // Ignore error in test
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL, 1)
+	client.retry.MaxDelay = 2 * time.Second // Retry-After is capped at MaxDelay
+	if _, err := client.FetchCategories(context.Background()); err != nil {
+		t.Fatalf("FetchCategories() error = %v", err)
+	}
+
+	if elapsed := time.Since(firstCall); elapsed < time.Second {
+		t.Errorf("retry happened after %s, want at least 1s from Retry-After", elapsed)
+	}
+}
+
+func TestEONETClient_CapsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// nolint:errcheck // Ignore error in test
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	// The test client waits at most 5ms between attempts
+	client := newRetryTestClient(server.URL, 1)
+	start := time.Now()
+	if _, err := client.FetchCategories(context.Background()); err != nil {
+		t.Fatalf("FetchCategories() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("retry happened after %s, want the Retry-After of a day capped at MaxDelay", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+
+	policy := RetryPolicy{MaxDelay: time.Minute}
+	for _, retryAfter := range []time.Duration{24 * time.Hour, 2 * time.Minute} {
+		if got := policy.backoff(1, &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter}); got != time.Minute {
+			t.Errorf("backoff() with Retry-After %s = %s, want 1m", retryAfter, got)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	cause := errors.New("connection reset")
+
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{attempt: 10, min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			got := policy.backoff(tt.attempt, cause)
+			if got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 22, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "seconds", value: "120", want: 2 * time.Minute},
+		{name: "http date", value: "Wed, 22 Jan 2025 10:00:30 GMT", want: 30 * time.Second},
+		{name: "date in the past", value: "Wed, 22 Jan 2025 09:00:00 GMT", want: 0},
+		{name: "negative", value: "-5", want: 0},
+		{name: "empty", value: "", want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEONETClient_CountsRequestStats(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// nolint:errcheck // Ignore error in test
+		_, _ = w.Write([]byte(`[{"id":"wildfires","title":"Wildfires"}]`))
+	}))
+	defer server.Close()
+
+	var stats RequestStats
+	client := newRetryTestClient(server.URL, 3)
+	if _, err := client.FetchCategories(WithRequestStats(context.Background(), &stats)); err != nil {
+		t.Fatalf("FetchCategories() error = %v", err)
+	}
+
+	if stats.Requests() != 2 {
+		t.Errorf("Requests() = %d, want 2", stats.Requests())
+	}
+	if want := int64(len(`[{"id":"wildfires","title":"Wildfires"}]`)); stats.Bytes() != want {
+		t.Errorf("Bytes() = %d, want %d", stats.Bytes(), want)
+	}
+
+	// Requests without stats in the context are not counted anywhere
+	if _, err := client.FetchCategories(context.Background()); err != nil {
+		t.Fatalf("FetchCategories() error = %v", err)
+	}
+	if stats.Requests() != 2 {
+		t.Errorf("Requests() after an untracked request = %d, want 2", stats.Requests())
+	}
+}
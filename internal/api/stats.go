@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RequestStats counts the requests sent to the API and the response bytes
+// read. Attach it to a context with WithRequestStats to measure the requests
+// made on behalf of that context, retries included.
+type RequestStats struct {
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+// Requests returns the number of requests sent
+func (s *RequestStats) Requests() int64 {
+	return s.requests.Load()
+}
+
+// Bytes returns the number of response body bytes read
+func (s *RequestStats) Bytes() int64 {
+	return s.bytes.Load()
+}
+
+type requestStatsKey struct{}
+
+// WithRequestStats returns a context whose API requests are counted in stats
+func WithRequestStats(ctx context.Context, stats *RequestStats) context.Context {
+	return context.WithValue(ctx, requestStatsKey{}, stats)
+}
+
+// requestStatsFrom returns the stats attached to ctx, or nil
+func requestStatsFrom(ctx context.Context) *RequestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*RequestStats)
+	return stats
+}
+
+func (s *RequestStats) addRequest() {
+	if s != nil {
+		s.requests.Add(1)
+	}
+}
+
+func (s *RequestStats) addBytes(n int) {
+	if s != nil {
+		s.bytes.Add(int64(n))
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/metrics"
+	"nasa-data-hub-etl/internal/tracing"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StreamResult summarizes the events passed on by StreamEvents
+type StreamResult struct {
+	Events            int  // Events passed to fn, each once
+	Invalid           int  // Invalid events that were skipped
+	InvalidGeometries int  // Invalid observations left out of the events passed to fn
+	Windows           int  // Date windows requested
+	Truncated         bool // A single day hit the limit, so events may be missing
+}
+
+// StreamEvents fetches the events matching opts and passes them to fn one at
+// a time while the response is decoded, so that memory use does not grow
+// with the result. Invalid events are logged and skipped. A failed request is
+// only retried until its first event was passed to fn. An error of fn ends
+// the stream and is returned as is.
+//
+// The API returns at most opts.Limit events. A date window that hits the
+// limit is split in halves that are fetched in turn, down to single days, so
+// that large ranges are paged through. An event that spans both halves is
+// only passed on by the half its last observation falls in, and the ids passed
+// on by a split window are kept until its halves are done, so that events are
+// passed once while no more than opts.Limit ids per split level are held.
+func (c *EONETClient) StreamEvents(ctx context.Context, opts FetchEventsOptions, fn func(models.Event) error) (result StreamResult, err error) {
+	ctx, span := tracing.Start(ctx, "eonet.fetch_events",
+		attribute.String("eonet.status", opts.Status),
+		attribute.String("eonet.category", opts.CategoryID),
+		attribute.Int("eonet.days", opts.Days),
+		attribute.Int("eonet.limit", opts.Limit),
+	)
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("etl.records", result.Events),
+			attribute.Int("etl.records.invalid", result.Invalid),
+			attribute.Int("etl.geometries.invalid", result.InvalidGeometries),
+			attribute.Int("eonet.windows", result.Windows),
+			attribute.Bool("eonet.truncated", result.Truncated),
+		)
+		tracing.End(span, err)
+	}()
+
+	stream := &eventStream{fn: fn, result: &result}
+	if err := c.streamWindow(ctx, opts, window{}, stream); err != nil {
+		return result, err
+	}
+
+	c.log(ctx).WithFields(logrus.Fields{
+		"events_count":       result.Events,
+		"invalid_events":     result.Invalid,
+		"invalid_geometries": result.InvalidGeometries,
+		"windows":            result.Windows,
+	}).Info("Successfully fetched events from NASA EONET API")
+
+	return result, nil
+}
+
+// eventStream is the state shared by the windows of a StreamEvents call
+type eventStream struct {
+	fn     func(models.Event) error
+	result *StreamResult
+	seen   map[string]bool // Ids passed on by the split windows being paged through
+}
+
+// window is the range of last observations of the events a window passes
+// on. A zero bound is open.
+type window struct {
+	from, until time.Time
+}
+
+// owns reports whether the event belongs to the window. The API selects
+// events by observation date, so the half of a split window that holds the
+// last observation of an event returns it, unlike a later closed date.
+func (w window) owns(event *models.Event) bool {
+	var latest time.Time
+	if last := event.LastObservation(); last != nil {
+		latest = last.Date
+	}
+	return (w.from.IsZero() || !latest.Before(w.from)) && (w.until.IsZero() || latest.Before(w.until))
+}
+
+// streamWindow streams the events of opts owned by owner and pages through
+// its halves if the response hits the limit
+func (c *EONETClient) streamWindow(ctx context.Context, opts FetchEventsOptions, owner window, stream *eventStream) error {
+	count, passed, err := c.streamResponse(ctx, opts, owner, stream)
+	if err != nil || opts.Limit <= 0 || count < opts.Limit {
+		return err
+	}
+
+	entry := c.log(ctx).WithFields(logrus.Fields{
+		"start": formatDate(opts.Start),
+		"end":   formatDate(opts.End),
+		"days":  opts.Days,
+		"limit": opts.Limit,
+	})
+
+	first, second, ok := splitWindow(opts, time.Now())
+	if !ok {
+		stream.result.Truncated = true
+		entry.Warn("Event result of a single day hit the limit, some events may be missing")
+		return nil
+	}
+
+	// The halves return the events passed on by this window again. They
+	// were not in seen before, so they are removed once the halves are done.
+	if stream.seen == nil {
+		stream.seen = make(map[string]bool, len(passed))
+	}
+	for _, id := range passed {
+		stream.seen[id] = true
+	}
+	defer func() {
+		for _, id := range passed {
+			delete(stream.seen, id)
+		}
+	}()
+
+	entry.Info("Event result hit the limit, paging through halves of the date window")
+	if err := c.streamWindow(ctx, first, window{from: owner.from, until: second.Start}, stream); err != nil {
+		return err
+	}
+	return c.streamWindow(ctx, second, window{from: second.Start, until: owner.until}, stream)
+}
+
+// streamResponse requests a single window and passes its events to fn,
+// skipping those seen before or not owned by owner. It returns the number of
+// events in the response, invalid ones included, and the ids of the events
+// passed to fn.
+func (c *EONETClient) streamResponse(ctx context.Context, opts FetchEventsOptions, owner window, stream *eventStream) (count int, passed []string, err error) {
+	result := stream.result
+	url := c.buildEventsURL(opts)
+
+	c.log(ctx).WithFields(logrus.Fields{
+		"url":  url,
+		"opts": opts,
+	}).Debug("Streaming events from NASA EONET API")
+	result.Windows++
+
+	var invalid int
+	var fnErr error
+	err = c.request(ctx, url, func(body io.Reader) error {
+		// A retry reads the response from the start
+		count, invalid = 0, 0
+
+		decoder := models.NewEventDecoder(body)
+		for {
+			event, err := decoder.Next()
+
+			var invalidErr *models.InvalidEventError
+			switch {
+			case errors.Is(err, io.EOF):
+				return nil
+			case errors.As(err, &invalidErr):
+				c.log(ctx).WithError(err).Warn("Skipping invalid event")
+				count++
+				invalid++
+				continue
+			case err != nil && len(passed) > 0:
+				return &permanentError{err: err}
+			case err != nil:
+				return err
+			}
+
+			count++
+			if stream.seen[event.ID] || !owner.owns(&event) {
+				continue
+			}
+			c.skipInvalidGeometries(ctx, &event)
+			result.InvalidGeometries += len(event.InvalidGeometries)
+			if fnErr = stream.fn(event); fnErr != nil {
+				// Stop reading, the response itself did not fail
+				return nil
+			}
+			passed = append(passed, event.ID)
+		}
+	})
+
+	result.Events += len(passed)
+	result.Invalid += invalid
+	metrics.EventsSkipped.WithLabelValues(metrics.SkipInvalid).Add(float64(invalid))
+
+	if fnErr != nil {
+		return 0, nil, fnErr
+	}
+	return count, passed, err
+}
+
+// skipInvalidGeometries logs and counts the observations left out of event
+func (c *EONETClient) skipInvalidGeometries(ctx context.Context, event *models.Event) {
+	for _, err := range event.InvalidGeometries {
+		c.log(ctx).WithError(err).WithField(logger.FieldEventID, event.ID).Warn("Skipping invalid geometry")
+	}
+	metrics.GeometriesSkipped.Add(float64(len(event.InvalidGeometries)))
+}
+
+// splitWindow splits the date range of opts in two halves. It fails for a
+// single day and for options without a date range.
+func splitWindow(opts FetchEventsOptions, now time.Time) (first, second FetchEventsOptions, ok bool) {
+	start, end := opts.Start, opts.End
+	if start.IsZero() && opts.Days > 0 {
+		start = now.AddDate(0, 0, -opts.Days)
+	}
+	if start.IsZero() {
+		return first, second, false
+	}
+	if end.IsZero() {
+		end = now
+	}
+
+	// The API takes whole days and both ends are inclusive
+	start, end = startOfDay(start), startOfDay(end)
+	days := int(end.Sub(start).Hours() / 24)
+	if days < 1 {
+		return first, second, false
+	}
+	middle := start.AddDate(0, 0, days/2)
+
+	first, second = opts, opts
+	first.Days, second.Days = 0, 0
+	first.Start, first.End = start, middle
+	second.Start, second.End = middle.AddDate(0, 0, 1), end
+	return first, second, true
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// formatDate formats t as a date for log fields, empty for the zero time
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
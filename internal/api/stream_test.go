@@ -0,0 +1,339 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+// newWindowedEventsServer serves one event per date in dates, filtered by the
+// start and end parameters and cut off at limit like the EONET API
+func newWindowedEventsServer(t *testing.T, dates []string, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		query := r.URL.Query()
+		start, end, limit := query.Get("start"), query.Get("end"), len(dates)
+		if v := query.Get("limit"); v != "" {
+			limit, _ = strconv.Atoi(v)
+		}
+
+		events := make([]string, 0, len(dates))
+		for i, date := range dates {
+			if (start != "" && date < start) || (end != "" && date > end) || len(events) == limit {
+				continue
+			}
+			events = append(events, fmt.Sprintf(`{"id":"EONET_%d","geometry":[{"date":"%sT00:00:00Z","type":"Point","coordinates":[1,2]}]}`, i, date))
+		}
+		fmt.Fprintf(w, `{"title":"EONET Events","events":[%s]}`, strings.Join(events, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// collectEvents streams opts and returns the distinct event ids in order
+func collectEvents(t *testing.T, client *EONETClient, opts FetchEventsOptions) ([]string, StreamResult) {
+	t.Helper()
+
+	seen := make(map[string]bool)
+	result, err := client.StreamEvents(context.Background(), opts, func(event models.Event) error {
+		seen[event.ID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, result
+}
+
+func TestEONETClient_StreamEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"events":[
+			{"id":"EONET_1","geometry":[{"type":"Point","coordinates":[1,2]}]},
+			{"id":"EONET_2","geometry":[{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1]]]}]},
+			{"id":"EONET_3","geometry":[]},
+			{"id":"EONET_4","geometry":[{"type":"Point","coordinates":[1,2]},{"type":"Point","coordinates":[1,200]}]}
+		]}`)
+	}))
+	defer server.Close()
+
+	ids, result := collectEvents(t, newRetryTestClient(server.URL, 0), FetchEventsOptions{Limit: 10})
+	if strings.Join(ids, ",") != "EONET_1,EONET_3,EONET_4" {
+		t.Errorf("streamed events = %v, want EONET_1, EONET_3 and EONET_4", ids)
+	}
+	if result != (StreamResult{Events: 3, Invalid: 1, InvalidGeometries: 1, Windows: 1}) {
+		t.Errorf("StreamEvents() result = %+v", result)
+	}
+}
+
+func TestEONETClient_StreamEventsPagesThroughWindows(t *testing.T) {
+	var requests atomic.Int32
+	dates := []string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-06", "2024-01-09"}
+	client := newRetryTestClient(newWindowedEventsServer(t, dates, &requests).URL, 0)
+
+	opts := FetchEventsOptions{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		Limit: 2,
+	}
+	ids, result := collectEvents(t, client, opts)
+
+	if len(ids) != len(dates) || result.Events != len(dates) {
+		t.Errorf("streamed events = %v in %d calls, want all %d once", ids, result.Events, len(dates))
+	}
+	if result.Truncated || result.Windows < 3 || int(requests.Load()) != result.Windows {
+		t.Errorf("StreamEvents() result = %+v after %d requests, want several windows and no truncation", result, requests.Load())
+	}
+}
+
+func TestEONETClient_StreamEventsSkipsDuplicatesAfterSplit(t *testing.T) {
+	// EONET_long spans the whole range and is returned for every window
+	dates := []string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-06", "2024-01-09"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		start, end := query.Get("start"), query.Get("end")
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		events := []string{`{"id":"EONET_long","geometry":[{"date":"2024-01-01T00:00:00Z","type":"Point","coordinates":[1,2]}]}`}
+		for i, date := range dates {
+			if date < start || date > end || len(events) == limit {
+				continue
+			}
+			events = append(events, fmt.Sprintf(`{"id":"EONET_%d","geometry":[{"date":"%sT00:00:00Z","type":"Point","coordinates":[1,2]}]}`, i, date))
+		}
+		fmt.Fprintf(w, `{"events":[%s]}`, strings.Join(events, ","))
+	}))
+	defer server.Close()
+
+	opts := FetchEventsOptions{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		Limit: 3,
+	}
+	calls := make(map[string]int)
+	result, err := newRetryTestClient(server.URL, 0).StreamEvents(context.Background(), opts, func(event models.Event) error {
+		calls[event.ID]++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+
+	if len(calls) != len(dates)+1 || result.Events != len(calls) {
+		t.Errorf("StreamEvents() passed %d events, %d distinct, want %d", result.Events, len(calls), len(dates)+1)
+	}
+	for id, n := range calls {
+		if n != 1 {
+			t.Errorf("event %s passed %d times, want once", id, n)
+		}
+	}
+	if result.Windows < 3 {
+		t.Errorf("StreamEvents() result = %+v, want a split window", result)
+	}
+}
+
+func TestEONETClient_StreamEventsBoundsDedupState(t *testing.T) {
+	// One event a day for 64 days, fetched two at a time, splits six times
+	var dates []string
+	for day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); len(dates) < 64; day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	var requests atomic.Int32
+	client := newRetryTestClient(newWindowedEventsServer(t, dates, &requests).URL, 0)
+
+	opts := FetchEventsOptions{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		Limit: 2,
+	}
+	var result StreamResult
+	calls := make(map[string]int)
+	maxSeen := 0
+	stream := &eventStream{result: &result}
+	stream.fn = func(event models.Event) error {
+		calls[event.ID]++
+		if len(stream.seen) > maxSeen {
+			maxSeen = len(stream.seen)
+		}
+		return nil
+	}
+	if err := client.streamWindow(context.Background(), opts, window{}, stream); err != nil {
+		t.Fatalf("streamWindow() error = %v", err)
+	}
+
+	if len(calls) != len(dates) || result.Events != len(dates) {
+		t.Errorf("streamWindow() passed %d events, %d distinct, want %d", result.Events, len(calls), len(dates))
+	}
+	for id, n := range calls {
+		if n != 1 {
+			t.Errorf("event %s passed %d times, want once", id, n)
+		}
+	}
+	// At most the limit per split level is held, and nothing once done
+	if maxSeen > opts.Limit*7 {
+		t.Errorf("dedup state held %d ids, want at most %d", maxSeen, opts.Limit*7)
+	}
+	if len(stream.seen) != 0 {
+		t.Errorf("dedup state holds %d ids after the stream, want none", len(stream.seen))
+	}
+}
+
+func TestEONETClient_StreamEventsTruncatedDay(t *testing.T) {
+	var requests atomic.Int32
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-02", "2024-01-02"}
+	client := newRetryTestClient(newWindowedEventsServer(t, dates, &requests).URL, 0)
+
+	opts := FetchEventsOptions{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Limit: 2,
+	}
+	ids, result := collectEvents(t, client, opts)
+
+	// January 2 holds three events but only two are returned for a single day
+	if !result.Truncated {
+		t.Errorf("StreamEvents() result = %+v, want truncated", result)
+	}
+	if len(ids) != 3 {
+		t.Errorf("streamed events = %v, want the event of January 1 and two of January 2", ids)
+	}
+}
+
+func TestEONETClient_StreamEventsCallbackError(t *testing.T) {
+	var requests atomic.Int32
+	client := newRetryTestClient(newWindowedEventsServer(t, []string{"2024-01-01", "2024-01-02"}, &requests).URL, 3)
+
+	errLoad := errors.New("load failed")
+	calls := 0
+	_, err := client.StreamEvents(context.Background(), FetchEventsOptions{}, func(models.Event) error {
+		calls++
+		return errLoad
+	})
+
+	if err != errLoad {
+		t.Errorf("StreamEvents() error = %v, want the callback error", err)
+	}
+	if calls != 1 || requests.Load() != 1 {
+		t.Errorf("callback called %d times after %d requests, want 1 and 1", calls, requests.Load())
+	}
+}
+
+func TestEONETClient_StreamEventsDoesNotRetryAfterFirstEvent(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// The connection ends in the middle of the second event
+		fmt.Fprint(w, `{"events":[{"id":"EONET_1","geometry":[]},{"id":"EON`)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL, 3)
+	result, err := client.StreamEvents(context.Background(), FetchEventsOptions{}, func(models.Event) error { return nil })
+	if err == nil {
+		t.Fatal("StreamEvents() expected error for a truncated response")
+	}
+	if requests.Load() != 1 || result.Events != 1 {
+		t.Errorf("StreamEvents() passed %d events in %d requests, want 1 in 1", result.Events, requests.Load())
+	}
+}
+
+func TestEONETClient_StreamEventsStall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("category") == "stalled" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		fmt.Fprint(w, `{"events":[{"id":"EONET_1","geometry":[]},{"id":"EONET_2","geometry":[]}]}`)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server.URL, 0)
+	client.timeout = 50 * time.Millisecond
+
+	// Time spent in the callback does not count against the timeout
+	slow := func(models.Event) error {
+		time.Sleep(80 * time.Millisecond)
+		return nil
+	}
+	if _, err := client.StreamEvents(context.Background(), FetchEventsOptions{}, slow); err != nil {
+		t.Errorf("StreamEvents() with a slow callback error = %v", err)
+	}
+
+	_, err := client.StreamEvents(context.Background(), FetchEventsOptions{CategoryID: "stalled"}, slow)
+	if !errors.Is(err, errStalled) {
+		t.Errorf("StreamEvents() from a stalled server error = %v, want errStalled", err)
+	}
+}
+
+func TestSplitWindow(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		opts   FetchEventsOptions
+		first  [2]time.Time
+		second [2]time.Time
+		ok     bool
+	}{
+		{
+			name:   "start and end",
+			opts:   FetchEventsOptions{Start: day(1), End: day(4)},
+			first:  [2]time.Time{day(1), day(2)},
+			second: [2]time.Time{day(3), day(4)},
+			ok:     true,
+		},
+		{
+			name:   "open end",
+			opts:   FetchEventsOptions{Start: day(8).Add(6 * time.Hour)},
+			first:  [2]time.Time{day(8), day(9)},
+			second: [2]time.Time{day(10), day(10)},
+			ok:     true,
+		},
+		{
+			name:   "days",
+			opts:   FetchEventsOptions{Days: 2},
+			first:  [2]time.Time{day(8), day(9)},
+			second: [2]time.Time{day(10), day(10)},
+			ok:     true,
+		},
+		{name: "single day", opts: FetchEventsOptions{Start: day(5), End: day(5)}},
+		{name: "no range", opts: FetchEventsOptions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second, ok := splitWindow(tt.opts, now)
+			if ok != tt.ok {
+				t.Fatalf("splitWindow() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !first.Start.Equal(tt.first[0]) || !first.End.Equal(tt.first[1]) ||
+				!second.Start.Equal(tt.second[0]) || !second.End.Equal(tt.second[1]) {
+				t.Errorf("splitWindow() = [%s, %s] [%s, %s]", formatDate(first.Start), formatDate(first.End),
+					formatDate(second.Start), formatDate(second.End))
+			}
+			if first.Days != 0 || second.Days != 0 {
+				t.Error("halves keep the days option")
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
@@ -15,6 +20,8 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	ETL      ETLConfig      `mapstructure:"etl"`
 	Server   ServerConfig   `mapstructure:"server"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Log      LogConfig      `mapstructure:"log"`
 }
 
 // NASAConfig holds NASA EONET API configuration
@@ -23,8 +30,17 @@ type NASAConfig struct {
 	APIKey string `mapstructure:"api_key"`
 }
 
-// DatabaseConfig holds VerticaDB configuration
+// Supported database drivers
+const (
+	DriverVertica  = "vertica"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite" // GeoPackage file, see DatabaseConfig.Path
+)
+
+// DatabaseConfig holds database configuration
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver"` // vertica, postgres or sqlite
+	Path     string `mapstructure:"path"`   // File used by the sqlite driver
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	Database string `mapstructure:"database"`
@@ -35,19 +51,68 @@ type DatabaseConfig struct {
 
 // ETLConfig holds ETL pipeline configuration
 type ETLConfig struct {
-	BatchSize     int           `mapstructure:"batch_size"`
-	Interval      time.Duration `mapstructure:"interval"`
-	RetryAttempts int           `mapstructure:"retry_attempts"`
-	RetryDelay    time.Duration `mapstructure:"retry_delay"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	Interval        time.Duration `mapstructure:"interval"`
+	RetryAttempts   int           `mapstructure:"retry_attempts"`
+	RetryDelay      time.Duration `mapstructure:"retry_delay"`
+	RetryMaxDelay   time.Duration `mapstructure:"retry_max_delay"`  // Cap of the backoff and of Retry-After hints
+	Overlap         time.Duration `mapstructure:"overlap"`          // Re-read window before the watermark
+	InitialLookback time.Duration `mapstructure:"initial_lookback"` // Window used when no watermark exists
+
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // How often a running run updates its heartbeat
+	StaleRunTimeout   time.Duration `mapstructure:"stale_run_timeout"`  // Runs without a heartbeat for this long are abandoned
+
+	LeaderElection bool          `mapstructure:"leader_election"` // Only the holder of the database lease runs the pipeline
+	LeaseTTL       time.Duration `mapstructure:"lease_ttl"`       // How long the lease stays valid without renewal
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port         int           `mapstructure:"port"`
+	AdminPort    int           `mapstructure:"admin_port"` // Port of the admin endpoints, disabled if 0
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 }
 
+// Supported trace exporters
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"   // OTLP over HTTP to a collector
+	TraceExporterStdout = "stdout" // Pretty printed JSON on standard output
+	TraceExporterFile   = "file"   // JSON lines appended to TracingConfig.FilePath
+)
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`     // none, otlp, stdout or file
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP collector host:port, the OTEL_EXPORTER_OTLP_* variables apply if empty
+	Insecure    bool    `mapstructure:"insecure"`     // Send OTLP over plain HTTP
+	FilePath    string  `mapstructure:"file_path"`    // Output of the file exporter
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Fraction of traces recorded, from 0 to 1
+}
+
+// Supported log formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text" // Human readable, colored on a terminal
+)
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string        `mapstructure:"level"`  // debug, info, warn or error, overridden by LOG_LEVEL
+	Format string        `mapstructure:"format"` // json or text, overridden by LOG_FORMAT
+	File   LogFileConfig `mapstructure:"file"`
+}
+
+// LogFileConfig holds the rotating log file written in addition to stdout
+type LogFileConfig struct {
+	Path       string `mapstructure:"path"`         // No log file if empty
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`  // Size at which the file is rotated
+	MaxBackups int    `mapstructure:"max_backups"`  // Rotated files to keep, 0 keeps all
+	MaxAgeDays int    `mapstructure:"max_age_days"` // Age at which rotated files are removed, 0 keeps them
+	Compress   bool   `mapstructure:"compress"`     // Gzip rotated files
+}
+
 // Load loads configuration from file and environment variables
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -91,21 +156,52 @@ func setDefaults() {
 	viper.SetDefault("nasa.api_url", "https://eonet.gsfc.nasa.gov/api/v3")
 	viper.SetDefault("nasa.api_key", "")
 
+	// Database defaults
+	viper.SetDefault("database.driver", DriverVertica)
+	viper.SetDefault("database.path", "nasa_data.gpkg")
+
 	// ETL defaults
 	viper.SetDefault("etl.batch_size", 1000)
 	viper.SetDefault("etl.interval", "1h")
 	viper.SetDefault("etl.retry_attempts", 3)
 	viper.SetDefault("etl.retry_delay", "30s")
+	viper.SetDefault("etl.retry_max_delay", "5m")
+	viper.SetDefault("etl.overlap", "24h")
+	viper.SetDefault("etl.initial_lookback", "720h")
+	viper.SetDefault("etl.heartbeat_interval", "30s")
+	viper.SetDefault("etl.stale_run_timeout", "5m")
+	viper.SetDefault("etl.leader_election", true)
+	viper.SetDefault("etl.lease_ttl", "30s")
 
 	// Server defaults
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
+	viper.SetDefault("server.admin_port", 0)
+
+	// Tracing defaults
+	viper.SetDefault("tracing.exporter", TraceExporterNone)
+	viper.SetDefault("tracing.file_path", "traces.json")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+
+	// Log defaults
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", LogFormatJSON)
+	viper.SetDefault("log.file.max_size_mb", 100)
+	viper.SetDefault("log.file.max_backups", 5)
+	viper.SetDefault("log.file.max_age_days", 30)
+	viper.SetDefault("log.file.compress", true)
 }
 
 // LoadSecrets loads sensitive configuration from environment variables
 func (c *Config) LoadSecrets() {
 	// Load database configuration from environment
+	if driver := os.Getenv("DATABASE_DRIVER"); driver != "" {
+		c.Database.Driver = driver
+	}
+	if path := os.Getenv("DATABASE_PATH"); path != "" {
+		c.Database.Path = path
+	}
 	if host := os.Getenv("DATABASE_HOST"); host != "" {
 		c.Database.Host = host
 	}
@@ -128,54 +224,178 @@ func (c *Config) LoadSecrets() {
 	if apiKey := os.Getenv("NASA_API_KEY"); apiKey != "" {
 		c.NASA.APIKey = apiKey
 	}
+
+	// Load log settings from environment
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		c.Log.Level = level
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		c.Log.Format = format
+	}
 }
 
+// staleRunHeartbeats is the least number of heartbeat intervals a run must
+// miss before it can be considered abandoned
+const staleRunHeartbeats = 3
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.NASA.APIURL == "" {
 		return fmt.Errorf("nasa.api_url is required")
 	}
 
+	switch c.Database.Driver {
+	case DriverVertica, DriverPostgres:
+		if err := c.Database.validateServer(); err != nil {
+			return err
+		}
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			return fmt.Errorf("database.path is required for the sqlite driver (set via DATABASE_PATH environment variable)")
+		}
+	default:
+		return fmt.Errorf("database.driver must be one of %s, %s, %s", DriverVertica, DriverPostgres, DriverSQLite)
+	}
+
+	if c.ETL.BatchSize <= 0 {
+		return fmt.Errorf("etl.batch_size must be greater than 0")
+	}
+
+	if c.ETL.Interval <= 0 {
+		return fmt.Errorf("etl.interval must be greater than 0")
+	}
+
+	if c.ETL.RetryAttempts < 0 {
+		return fmt.Errorf("etl.retry_attempts must be non-negative")
+	}
+
+	if c.ETL.RetryMaxDelay < c.ETL.RetryDelay {
+		return fmt.Errorf("etl.retry_max_delay must be at least etl.retry_delay")
+	}
+
+	if c.ETL.Overlap < 0 {
+		return fmt.Errorf("etl.overlap must be non-negative")
+	}
+
+	if c.ETL.InitialLookback <= 0 {
+		return fmt.Errorf("etl.initial_lookback must be greater than 0")
+	}
+
+	if c.ETL.HeartbeatInterval <= 0 {
+		return fmt.Errorf("etl.heartbeat_interval must be greater than 0")
+	}
+
+	// A live run may miss a heartbeat or two, e.g. on a slow database
+	if c.ETL.StaleRunTimeout < staleRunHeartbeats*c.ETL.HeartbeatInterval {
+		return fmt.Errorf("etl.stale_run_timeout must be at least %d times etl.heartbeat_interval", staleRunHeartbeats)
+	}
+
+	if c.ETL.LeaderElection && c.ETL.LeaseTTL < time.Second {
+		return fmt.Errorf("etl.lease_ttl must be at least 1s")
+	}
+
+	if c.Server.AdminPort < 0 || c.Server.AdminPort > 65535 {
+		return fmt.Errorf("server.admin_port must be between 0 and 65535")
+	}
+
+	if c.Server.AdminPort != 0 && c.Server.AdminPort == c.Server.Port {
+		return fmt.Errorf("server.admin_port must differ from server.port")
+	}
+
+	switch c.Tracing.Exporter {
+	case "", TraceExporterNone, TraceExporterOTLP, TraceExporterStdout:
+	case TraceExporterFile:
+		if c.Tracing.FilePath == "" {
+			return fmt.Errorf("tracing.file_path is required for the file exporter")
+		}
+	default:
+		return fmt.Errorf("tracing.exporter must be one of %s, %s, %s, %s",
+			TraceExporterNone, TraceExporterOTLP, TraceExporterStdout, TraceExporterFile)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		return fmt.Errorf("log.level must be one of debug, info, warn, error: %w", err)
+	}
+
+	switch c.Log.Format {
+	case LogFormatJSON, LogFormatText:
+	default:
+		return fmt.Errorf("log.format must be %s or %s", LogFormatJSON, LogFormatText)
+	}
+
+	if c.Log.File.Path != "" && c.Log.File.MaxSizeMB <= 0 {
+		return fmt.Errorf("log.file.max_size_mb must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateServer validates the connection settings of a database server
+func (c *DatabaseConfig) validateServer() error {
 	// Database configuration is loaded from environment variables
-	if c.Database.Host == "" {
+	if c.Host == "" {
 		return fmt.Errorf("database.host is required (set via DATABASE_HOST environment variable)")
 	}
 
-	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+	if c.Port <= 0 || c.Port > 65535 {
 		return fmt.Errorf("database.port must be between 1 and 65535 (set via DATABASE_PORT environment variable)")
 	}
 
-	if c.Database.Database == "" {
+	if c.Database == "" {
 		return fmt.Errorf("database.database is required (set via DATABASE_NAME environment variable)")
 	}
 
-	if c.Database.Username == "" {
+	if c.Username == "" {
 		return fmt.Errorf("database.username is required (set via DATABASE_USERNAME environment variable)")
 	}
 
-	if c.Database.Password == "" {
+	if c.Password == "" {
 		return fmt.Errorf("database.password is required (set via DATABASE_PASSWORD environment variable)")
 	}
 
-	if c.ETL.BatchSize <= 0 {
-		return fmt.Errorf("etl.batch_size must be greater than 0")
-	}
+	return nil
+}
 
-	if c.ETL.RetryAttempts < 0 {
-		return fmt.Errorf("etl.retry_attempts must be non-negative")
+// Hash returns a SHA-256 fingerprint of the configuration without secrets.
+// It is recorded with every ETL run to tell which settings produced it.
+func (c *Config) Hash() string {
+	redacted := *c
+	redacted.Database.Password = ""
+	redacted.NASA.APIKey = ""
+
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return ""
 	}
 
-	return nil
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 // GetDatabaseDSN returns the database connection string
 func (c *Config) GetDatabaseDSN() string {
-	return fmt.Sprintf("vertica://%s:%s@%s:%d/%s?sslmode=%s",
-		c.Database.Username,
-		c.Database.Password,
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.Database,
-		c.Database.SSLMode,
-	)
+	return c.Database.DSN()
+}
+
+// DSN returns the connection string for the configured driver
+func (c *DatabaseConfig) DSN() string {
+	scheme := c.Driver
+	if scheme == "" {
+		scheme = DriverVertica
+	}
+
+	dsn := url.URL{
+		Scheme: scheme,
+		User:   url.UserPassword(c.Username, c.Password),
+		Host:   fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:   "/" + c.Database,
+	}
+	if c.SSLMode != "" || scheme == DriverVertica {
+		dsn.RawQuery = "sslmode=" + url.QueryEscape(c.SSLMode)
+	}
+	return dsn.String()
 }
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+// gpkgSRSWGS84 is the srs_id of WGS 84 in gpkg_spatial_ref_sys
+const gpkgSRSWGS84 = 4326
+
+// WKB geometry type codes
+const (
+	wkbPoint        = 1
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+)
+
+// encodeGeoPackageGeometry encodes GeoJSON coordinates of the given geometry
+// type as a GeoPackage geometry blob: the GP header with srs id and envelope
+// followed by little-endian WKB. It returns nil for missing coordinates.
+func encodeGeoPackageGeometry(geometryType, coordinates string) ([]byte, error) {
+	if coordinates == "" || coordinates == "null" {
+		return nil, nil
+	}
+
+	var geometry models.Geometry
+	if err := json.Unmarshal([]byte(fmt.Sprintf(`{"type":%q,"coordinates":%s}`, geometryType, coordinates)), &geometry); err != nil {
+		return nil, fmt.Errorf("failed to decode geometry: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("GP")
+	buf.WriteByte(0) // version 1
+
+	// Flags: little endian, envelope [minx, maxx, miny, maxy] except for points
+	_, isPoint := geometry.Coordinates.(models.Point)
+	if isPoint {
+		buf.WriteByte(0x01)
+	} else {
+		buf.WriteByte(0x03)
+	}
+	writeLE(&buf, int32(gpkgSRSWGS84))
+	if !isPoint {
+		box := geometry.Coordinates.BBox()
+		writeLE(&buf, [4]float64{box.MinLon, box.MaxLon, box.MinLat, box.MaxLat})
+	}
+
+	switch c := geometry.Coordinates.(type) {
+	case models.Point:
+		writeWKBHeader(&buf, wkbPoint)
+		writeLE(&buf, [2]float64{c.Lon, c.Lat})
+	case models.Polygon:
+		writeWKBPolygon(&buf, c)
+	case models.MultiPolygon:
+		writeWKBHeader(&buf, wkbMultiPolygon)
+		writeLE(&buf, uint32(len(c)))
+		for _, polygon := range c {
+			writeWKBPolygon(&buf, polygon)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", geometryType)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeWKBHeader(buf *bytes.Buffer, geometryType uint32) {
+	buf.WriteByte(1) // little endian
+	writeLE(buf, geometryType)
+}
+
+func writeWKBPolygon(buf *bytes.Buffer, polygon models.Polygon) {
+	writeWKBHeader(buf, wkbPolygon)
+	writeLE(buf, uint32(len(polygon)))
+	for _, ring := range polygon {
+		writeLE(buf, uint32(len(ring)))
+		for _, p := range ring {
+			writeLE(buf, [2]float64{p.Lon, p.Lat})
+		}
+	}
+}
+
+// writeLE writes a fixed-size value in little-endian order, writes to a
+// bytes.Buffer cannot fail
+func writeLE(buf *bytes.Buffer, value any) {
+	_ = binary.Write(buf, binary.LittleEndian, value)
+}
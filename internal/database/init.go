@@ -13,132 +13,50 @@ import (
 type InitMode string
 
 const (
-	InitModeCreate InitMode = "Create" // Create database structure
-	InitModeRevive InitMode = "Revive" // Skip structure creation
-	InitModeAuto   InitMode = "Auto"   // Auto-detect if structure exists
+	InitModeCreate  InitMode = "Create"  // Apply all pending migrations
+	InitModeRevive  InitMode = "Revive"  // Skip migrations, fail unless the schema version matches
+	InitModeAuto    InitMode = "Auto"    // Apply pending migrations if there are any
+	InitModeMigrate InitMode = "Migrate" // Apply pending migrations and exit
 )
 
-// InitializeDatabase creates database structure based on mode
+// InitializeDatabase brings the database schema up to date based on mode. In
+// every mode it refuses to continue if the schema is newer than the binary,
+// and in revive mode also if it is older.
 func (v *VerticaDB) InitializeDatabase(ctx context.Context, mode InitMode) error {
-	switch mode {
-	case InitModeCreate:
-		return v.createDatabaseStructure(ctx)
-	case InitModeRevive:
-		v.logger.Info("Database initialization skipped (revive mode)")
-		return nil
-	case InitModeAuto:
-		return v.autoInitializeDatabase(ctx)
-	default:
-		return fmt.Errorf("unknown initialization mode: %s, supported modes: Create, Revive, Auto", mode)
-	}
-}
-
-// createDatabaseStructure creates all required tables and indexes
-func (v *VerticaDB) createDatabaseStructure(ctx context.Context) error {
-	v.logger.Info("Creating database structure...")
-
-	// Create events table
-	if err := v.createEventsTable(ctx); err != nil {
-		return fmt.Errorf("failed to create events table: %w", err)
-	}
-
-	// Create categories table
-	if err := v.createCategoriesTable(ctx); err != nil {
-		return fmt.Errorf("failed to create categories table: %w", err)
-	}
-
-	// Create indexes
-	if err := v.createIndexes(ctx); err != nil {
-		return fmt.Errorf("failed to create indexes: %w", err)
-	}
-
-	v.logger.Info("Database structure created successfully")
-	return nil
-}
-
-// createEventsTable creates the events table
-func (v *VerticaDB) createEventsTable(ctx context.Context) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS events (
-		id VARCHAR(255) PRIMARY KEY,
-		title VARCHAR(500),
-		description VARCHAR(10000),
-		link VARCHAR(1000),
-		categories VARCHAR(10000),
-		sources VARCHAR(10000),
-		geometries VARCHAR(10000),
-		date TIMESTAMP,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
-
-	_, err := v.db.ExecContext(ctx, query)
+	migrator, err := v.Migrator()
 	if err != nil {
-		return fmt.Errorf("failed to execute events table creation: %w", err)
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	v.logger.Info("Events table created successfully")
-	return nil
+	return initializeWithMigrator(ctx, migrator, mode)
 }
 
-// createCategoriesTable creates the categories table
-func (v *VerticaDB) createCategoriesTable(ctx context.Context) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS categories (
-		id INT PRIMARY KEY,
-		title VARCHAR(255) NOT NULL,
-		description VARCHAR(10000),
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
-
-	_, err := v.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to execute categories table creation: %w", err)
-	}
-
-	v.logger.Info("Categories table created successfully")
-	return nil
-}
-
-// createIndexes creates performance indexes
-func (v *VerticaDB) createIndexes(ctx context.Context) error {
-	indexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_events_date ON events(date)",
-		"CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_categories_title ON categories(title)",
-	}
-
-	for _, indexQuery := range indexes {
-		if _, err := v.db.ExecContext(ctx, indexQuery); err != nil {
-			return fmt.Errorf("failed to create index '%s': %w", indexQuery, err)
+// initializeWithMigrator implements InitializeDatabase for any migrator
+func initializeWithMigrator(ctx context.Context, migrator *Migrator, mode InitMode) error {
+	switch mode {
+	case InitModeCreate, InitModeAuto, InitModeMigrate:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
 		}
-	}
-
-	v.logger.Info("Database indexes created successfully")
-	return nil
-}
-
-// autoInitializeDatabase automatically detects if database structure exists and creates it if needed
-func (v *VerticaDB) autoInitializeDatabase(ctx context.Context) error {
-	v.logger.Info("Auto-detecting database structure...")
-
-	// Check if events table exists using VerticaDB-specific query
-	var tableCount int
-	query := `SELECT COUNT(*) FROM v_catalog.tables WHERE table_name = 'events' AND table_schema = 'public'`
-	err := v.db.QueryRowContext(ctx, query).Scan(&tableCount)
-	if err != nil {
-		v.logger.Info("Database structure not found, creating...")
-		return v.createDatabaseStructure(ctx)
-	}
-
-	if tableCount > 0 {
-		v.logger.Info("Database structure already exists, skipping creation")
+		if applied > 0 {
+			migrator.logger.WithField("applied", applied).Info("Database migrations applied successfully")
+		}
+		return nil
+	case InitModeRevive:
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: %d pending migrations starting with %d_%s, run with --db-init=Migrate or Auto",
+				ErrSchemaBehind, len(pending), pending[0].Version, pending[0].Name)
+		}
+		migrator.logger.Info("Database initialization skipped (revive mode)")
 		return nil
+	default:
+		return fmt.Errorf("unknown initialization mode: %s, supported modes: Create, Revive, Auto, Migrate", mode)
 	}
-
-	v.logger.Info("Database structure not found, creating...")
-	return v.createDatabaseStructure(ctx)
 }
 
 // ValidateInitMode validates the initialization mode
@@ -151,7 +69,9 @@ func ValidateInitMode(mode string) (InitMode, error) {
 		return InitModeRevive, nil
 	case "Auto":
 		return InitModeAuto, nil
+	case "Migrate":
+		return InitModeMigrate, nil
 	default:
-		return "", fmt.Errorf("invalid initialization mode: %s, supported modes: Create, Revive, Auto", mode)
+		return "", fmt.Errorf("invalid initialization mode: %s, supported modes: Create, Revive, Auto, Migrate", mode)
 	}
 }
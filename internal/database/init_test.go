@@ -29,6 +29,12 @@ func TestValidateInitMode(t *testing.T) {
 			want:    InitModeAuto,
 			wantErr: false,
 		},
+		{
+			name:    "migrate mode",
+			mode:    "migrate",
+			want:    InitModeMigrate,
+			wantErr: false,
+		},
 		{
 			name:    "case insensitive",
 			mode:    "CREATE",
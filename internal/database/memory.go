@@ -0,0 +1,415 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore is a Store that keeps everything in memory. It is meant for
+// tests and local experiments, nothing survives a restart.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	events     map[string]*models.EventRecord
+	categories map[string]*models.CategoryRecord
+	runs       []ETLRunInfo
+	watermarks map[string]time.Time
+	chunks     map[[2]time.Time]BackfillChunk
+	leases     map[string]memoryLease
+	closed     bool
+}
+
+type memoryLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		events:     make(map[string]*models.EventRecord),
+		categories: make(map[string]*models.CategoryRecord),
+		watermarks: make(map[string]time.Time),
+		chunks:     make(map[[2]time.Time]BackfillChunk),
+		leases:     make(map[string]memoryLease),
+	}
+}
+
+// InitializeDatabase only validates the mode, there is no schema to migrate
+func (m *MemoryStore) InitializeDatabase(ctx context.Context, mode InitMode) error {
+	if _, err := ValidateInitMode(string(mode)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpsertEvents stores copies of new and changed events
+func (m *MemoryStore) UpsertEvents(ctx context.Context, events []*models.EventRecord) (UpsertResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Child rows are stored with their event, so every stored event has them
+	withChildren := make(map[string]bool, len(m.events))
+	for id := range m.events {
+		withChildren[id] = true
+	}
+
+	inserts, updates, result := classifyEvents(m.events, withChildren, events)
+
+	now := time.Now().UTC()
+	for _, event := range inserts {
+		record := *event
+		record.CreatedAt, record.UpdatedAt = now, now
+		m.events[record.ID] = &record
+	}
+	for _, event := range updates {
+		record := *event
+		record.CreatedAt, record.UpdatedAt = m.events[record.ID].CreatedAt, now
+		m.events[record.ID] = &record
+	}
+
+	return result, nil
+}
+
+// UpsertCategories stores copies of new and changed categories
+func (m *MemoryStore) UpsertCategories(ctx context.Context, categories []*models.CategoryRecord) (UpsertResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inserts, updates, result := classifyCategories(m.categories, categories)
+
+	now := time.Now().UTC()
+	for _, category := range inserts {
+		record := *category
+		record.CreatedAt, record.UpdatedAt = now, now
+		m.categories[record.ID] = &record
+	}
+	for _, category := range updates {
+		record := *category
+		record.CreatedAt, record.UpdatedAt = m.categories[record.ID].CreatedAt, now
+		m.categories[record.ID] = &record
+	}
+
+	return result, nil
+}
+
+// Events returns copies of the stored events ordered by id
+func (m *MemoryStore) Events() []models.EventRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]models.EventRecord, 0, len(m.events))
+	for _, event := range m.events {
+		events = append(events, *event)
+	}
+	slices.SortFunc(events, func(a, b models.EventRecord) int { return strings.Compare(a.ID, b.ID) })
+	return events
+}
+
+// Categories returns copies of the stored categories ordered by id
+func (m *MemoryStore) Categories() []models.CategoryRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	categories := make([]models.CategoryRecord, 0, len(m.categories))
+	for _, category := range m.categories {
+		categories = append(categories, *category)
+	}
+	slices.SortFunc(categories, func(a, b models.CategoryRecord) int { return strings.Compare(a.ID, b.ID) })
+	return categories
+}
+
+// ListEvents returns copies of the events matching filter ordered by id
+func (m *MemoryStore) ListEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error) {
+	var events []*models.EventRecord
+	for _, event := range m.Events() {
+		if filter.matchesEvent(&event) {
+			events = append(events, &event)
+		}
+	}
+
+	if filter.Limit > 0 {
+		start := min(max(filter.Offset, 0), len(events))
+		events = events[start:min(start+filter.Limit, len(events))]
+	}
+	return events, nil
+}
+
+// CountEvents counts the events matching filter
+func (m *MemoryStore) CountEvents(ctx context.Context, filter EventFilter) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, event := range m.events {
+		if filter.matchesEvent(event) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetEvent returns a copy of an event, or nil if it does not exist
+func (m *MemoryStore) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	event, ok := m.events[id]
+	if !ok {
+		return nil, nil
+	}
+	record := *event
+	return &record, nil
+}
+
+// ListCategories returns copies of all categories ordered by id
+func (m *MemoryStore) ListCategories(ctx context.Context) ([]*models.CategoryRecord, error) {
+	var categories []*models.CategoryRecord
+	for _, category := range m.Categories() {
+		categories = append(categories, &category)
+	}
+	return categories, nil
+}
+
+// StartETLRun records the start of an ETL run, run ids count up from 1
+func (m *MemoryStore) StartETLRun(ctx context.Context, start ETLRunStart) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	run := ETLRunInfo{
+		ID:          int64(len(m.runs) + 1),
+		StartedAt:   now,
+		HeartbeatAt: &now,
+		Status:      "running",
+		AppVersion:  start.AppVersion,
+		GitCommit:   start.GitCommit,
+		ConfigHash:  start.ConfigHash,
+	}
+	m.runs = append(m.runs, run)
+	return run.ID, nil
+}
+
+// CompleteETLRun records the completion of an ETL run
+func (m *MemoryStore) CompleteETLRun(ctx context.Context, runID int64, result ETLRunResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if runID < 1 || runID > int64(len(m.runs)) {
+		return fmt.Errorf("failed to complete ETL run: run %d not found", runID)
+	}
+
+	now := time.Now().UTC()
+	run := &m.runs[runID-1]
+	run.CompletedAt = &now
+	run.Status = result.Status
+	run.EventsProcessed = result.EventsProcessed
+	run.CategoriesProcessed = result.CategoriesProcessed
+	run.ErrorMessage = result.ErrorMessage
+	run.ExtractDurationMs = result.ExtractDurationMs
+	run.TransformDurationMs = result.TransformDurationMs
+	run.LoadDurationMs = result.LoadDurationMs
+	run.APIRequests = result.APIRequests
+	run.BytesDownloaded = result.BytesDownloaded
+	return nil
+}
+
+// HeartbeatETLRun records that a running run is still alive
+func (m *MemoryStore) HeartbeatETLRun(ctx context.Context, runID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if runID < 1 || runID > int64(len(m.runs)) {
+		return fmt.Errorf("failed to record ETL run heartbeat: run %d not found", runID)
+	}
+
+	if run := &m.runs[runID-1]; run.Status == "running" {
+		now := time.Now().UTC()
+		run.HeartbeatAt = &now
+	}
+	return nil
+}
+
+// AbandonStaleETLRuns marks running runs without a heartbeat within timeout as abandoned
+func (m *MemoryStore) AbandonStaleETLRuns(ctx context.Context, timeout time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	cutoff := now.Add(-timeout)
+	msg := abandonedMessage(timeout)
+
+	var abandoned int64
+	for i := range m.runs {
+		run := &m.runs[i]
+		lastSeen := run.StartedAt
+		if run.HeartbeatAt != nil {
+			lastSeen = *run.HeartbeatAt
+		}
+		if run.Status != "running" || !lastSeen.Before(cutoff) {
+			continue
+		}
+
+		run.Status = "abandoned"
+		run.CompletedAt = &now
+		run.ErrorMessage = &msg
+		abandoned++
+	}
+	return abandoned, nil
+}
+
+// AcquireLease takes or renews a lease
+func (m *MemoryStore) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := m.leases[name]; ok && lease.owner != owner && !lease.expiresAt.Before(now) {
+		return false, nil
+	}
+	m.leases[name] = memoryLease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease expires the lease if owner holds it
+func (m *MemoryStore) ReleaseLease(ctx context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lease, ok := m.leases[name]; ok && lease.owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+// GetLastETLRun returns the most recently started run
+func (m *MemoryStore) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.runs) == 0 {
+		return nil, nil
+	}
+	run := m.runs[len(m.runs)-1]
+	return &run, nil
+}
+
+// ListETLRuns returns copies of the runs, most recently started first
+func (m *MemoryStore) ListETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Ids count up, so the newest run is last
+	var runs []*ETLRunInfo
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		run := m.runs[i]
+		runs = append(runs, &run)
+	}
+
+	if limit > 0 {
+		start := min(max(offset, 0), len(runs))
+		runs = runs[start:min(start+limit, len(runs))]
+	}
+	return runs, nil
+}
+
+// GetETLRun returns a copy of a run, or nil if it does not exist
+func (m *MemoryStore) GetETLRun(ctx context.Context, id int64) (*ETLRunInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id < 1 || id > int64(len(m.runs)) {
+		return nil, nil
+	}
+	run := m.runs[id-1]
+	return &run, nil
+}
+
+// GetWatermark returns the high-water mark of a source, or nil if none was recorded
+func (m *MemoryStore) GetWatermark(ctx context.Context, source string) (*time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	watermark, ok := m.watermarks[source]
+	if !ok {
+		return nil, nil
+	}
+	return &watermark, nil
+}
+
+// SetWatermark records the high-water mark of a source
+func (m *MemoryStore) SetWatermark(ctx context.Context, source string, watermark time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.watermarks[source] = watermark.UTC()
+	return nil
+}
+
+// ResetWatermark removes the high-water mark of a source
+func (m *MemoryStore) ResetWatermark(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.watermarks, source)
+	return nil
+}
+
+// ListBackfillChunks returns the recorded chunks that lie within [from, to)
+func (m *MemoryStore) ListBackfillChunks(ctx context.Context, from, to time.Time) ([]BackfillChunk, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var chunks []BackfillChunk
+	for _, chunk := range m.chunks {
+		if !chunk.Start.Before(from) && !chunk.End.After(to) {
+			chunks = append(chunks, chunk)
+		}
+	}
+	slices.SortFunc(chunks, func(a, b BackfillChunk) int { return a.Start.Compare(b.Start) })
+	return chunks, nil
+}
+
+// SaveBackfillChunk records the progress of a backfill chunk
+func (m *MemoryStore) SaveBackfillChunk(ctx context.Context, chunk *BackfillChunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]time.Time{chunk.Start.UTC(), chunk.End.UTC()}
+	saved := *chunk
+	saved.Start, saved.End = key[0], key[1]
+	if existing, ok := m.chunks[key]; ok {
+		saved.StartedAt = existing.StartedAt
+	} else {
+		saved.StartedAt = time.Now().UTC()
+	}
+	m.chunks[key] = saved
+	return nil
+}
+
+// HealthCheck fails once the store is closed
+func (m *MemoryStore) HealthCheck(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return fmt.Errorf("memory store is closed")
+	}
+	return nil
+}
+
+// Close marks the store as closed
+func (m *MemoryStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	return nil
+}
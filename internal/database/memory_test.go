@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+func TestMemoryStore_UpsertEvents(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	result, err := store.UpsertEvents(ctx, []*models.EventRecord{
+		{ID: "EONET_1", Title: "Wildfire A"},
+		{ID: "EONET_2", Title: "Storm B"},
+	})
+	if err != nil || result != (UpsertResult{Inserted: 2}) {
+		t.Fatalf("first UpsertEvents() = %+v, %v", result, err)
+	}
+	created := store.Events()[0].CreatedAt
+
+	result, err = store.UpsertEvents(ctx, []*models.EventRecord{
+		{ID: "EONET_1", Title: "Wildfire A (renamed)"},
+		{ID: "EONET_2", Title: "Storm B"},
+	})
+	if err != nil || result != (UpsertResult{Updated: 1, Unchanged: 1}) {
+		t.Fatalf("second UpsertEvents() = %+v, %v", result, err)
+	}
+
+	events := store.Events()
+	if events[0].Title != "Wildfire A (renamed)" || !events[0].CreatedAt.Equal(created) {
+		t.Errorf("updated event = %+v, want new title and original created_at", events[0])
+	}
+}
+
+func TestMemoryStore_Runs(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if run, err := store.GetLastETLRun(ctx); run != nil || err != nil {
+		t.Fatalf("GetLastETLRun() on empty store = %v, %v", run, err)
+	}
+
+	first, _ := store.StartETLRun(ctx, ETLRunStart{})
+	second, _ := store.StartETLRun(ctx, ETLRunStart{AppVersion: "1.2.3", ConfigHash: "abc"})
+	if first == second {
+		t.Fatalf("run ids should be unique, got %d twice", first)
+	}
+
+	if err := store.CompleteETLRun(ctx, second, ETLRunResult{Status: "completed", EventsProcessed: 5, CategoriesProcessed: 1, APIRequests: 2}); err != nil {
+		t.Fatalf("CompleteETLRun() error = %v", err)
+	}
+	if err := store.CompleteETLRun(ctx, 99, ETLRunResult{Status: "completed"}); err == nil {
+		t.Error("CompleteETLRun() of an unknown run should fail")
+	}
+
+	run, _ := store.GetLastETLRun(ctx)
+	if run.ID != second || run.Status != "completed" || run.EventsProcessed != 5 || run.APIRequests != 2 || run.AppVersion != "1.2.3" {
+		t.Errorf("GetLastETLRun() = %+v", run)
+	}
+}
+
+func TestMemoryStore_BackfillChunks(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	mar := feb.AddDate(0, 1, 0)
+
+	store.SaveBackfillChunk(ctx, &BackfillChunk{Start: feb, End: mar, Status: "running"})
+	store.SaveBackfillChunk(ctx, &BackfillChunk{Start: jan, End: feb, Status: "completed"})
+
+	chunks, err := store.ListBackfillChunks(ctx, jan, feb)
+	if err != nil || len(chunks) != 1 || chunks[0].Status != "completed" {
+		t.Errorf("ListBackfillChunks(jan, feb) = %+v, %v", chunks, err)
+	}
+
+	chunks, _ = store.ListBackfillChunks(ctx, jan, mar)
+	if len(chunks) != 2 || !chunks[0].Start.Equal(jan) {
+		t.Errorf("ListBackfillChunks(jan, mar) = %+v, want both chunks in order", chunks)
+	}
+}
+
+func TestMemoryStore_AbandonStaleRuns(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	stale, _ := store.StartETLRun(ctx, ETLRunStart{})
+	done, _ := store.StartETLRun(ctx, ETLRunStart{})
+	if err := store.CompleteETLRun(ctx, done, ETLRunResult{Status: "completed"}); err != nil {
+		t.Fatalf("CompleteETLRun() error = %v", err)
+	}
+
+	if n, err := store.AbandonStaleETLRuns(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("AbandonStaleETLRuns(1h) = %d, %v, want 0", n, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n, err := store.AbandonStaleETLRuns(ctx, time.Millisecond); err != nil || n != 1 {
+		t.Fatalf("AbandonStaleETLRuns(1ms) = %d, %v, want 1", n, err)
+	}
+
+	if run := store.runs[stale-1]; run.Status != "abandoned" || run.CompletedAt == nil || run.ErrorMessage == nil {
+		t.Errorf("stale run = %+v, want abandoned", run)
+	}
+	if run := store.runs[done-1]; run.Status != "completed" {
+		t.Errorf("completed run = %+v, want it untouched", run)
+	}
+}
@@ -0,0 +1,482 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/vertica/*.sql migrations/postgres/*.sql migrations/sqlite/*.sql
+var embeddedMigrations embed.FS
+
+// ErrSchemaAhead is returned when the database has migrations applied that
+// this binary doesn't know about, i.e. it was migrated by a newer release
+var ErrSchemaAhead = errors.New("database schema is newer than this binary")
+
+// ErrSchemaBehind is returned in revive mode when the database misses
+// migrations that this binary needs
+var ErrSchemaBehind = errors.New("database schema is older than this binary")
+
+// migrationFilePattern matches NNNN_name.up.sql and NNNN_name.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up script
+}
+
+// AppliedMigration is a row of the schema_migrations table
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// MigrationStatus describes a known migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from dir,
+// ordered by version
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 1, missing version %d", i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// pendingMigrations compares known migrations with the applied ones and returns
+// those still to apply. It fails if the database is ahead of the binary or if
+// an applied migration was modified after it ran.
+func pendingMigrations(known []Migration, applied []AppliedMigration) ([]Migration, error) {
+	byVersion := make(map[int]Migration, len(known))
+	latest := 0
+	for _, migration := range known {
+		byVersion[migration.Version] = migration
+		latest = max(latest, migration.Version)
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		migration, ok := byVersion[a.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: database is at version %d (%s), binary only knows up to version %d",
+				ErrSchemaAhead, a.Version, a.Name, latest)
+		}
+		if migration.Checksum != a.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for migration %d_%s: it was changed after being applied",
+				a.Version, a.Name)
+		}
+		done[a.Version] = true
+	}
+
+	pending := make([]Migration, 0, len(known))
+	for _, migration := range known {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// splitStatements splits a SQL script into statements on ';', ignoring
+// semicolons inside quoted strings and '--' comments
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	inQuote, inComment := false, false
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		switch {
+		case inComment:
+			if c == '\n' {
+				inComment = false
+				current.WriteByte(c)
+			}
+		case inQuote:
+			current.WriteByte(c)
+			if c == '\'' {
+				inQuote = false
+			}
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			inComment = true
+			i++
+		case c == '\'':
+			inQuote = true
+			current.WriteByte(c)
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// Migrator applies and rolls back schema migrations, recording them in the
+// schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *logrus.Logger
+	rebind     func(query string) string // Adapts '?' placeholders to the driver
+
+	// Runs each migration and its record in one transaction, for drivers
+	// with transactional DDL
+	transactional bool
+	// Serializes migrations of concurrent processes, nil if the driver
+	// needs no lock besides the transaction
+	lock func(ctx context.Context) (unlock func(), err error)
+}
+
+// execer runs statements on the database or in a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// NewMigrator creates a new migrator for the given migrations
+func NewMigrator(db *sql.DB, migrations []Migration, logger *logrus.Logger) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+		rebind:     func(query string) string { return query },
+	}
+}
+
+// ensureTable creates the schema_migrations table if it doesn't exist
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`
+
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// Applied returns the applied migrations ordered by version
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied = append(applied, a)
+	}
+
+	return applied, rows.Err()
+}
+
+// Pending returns the migrations not applied yet. It fails with ErrSchemaAhead
+// if the database was migrated by a newer binary.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(m.migrations, applied)
+}
+
+// Status returns every known migration with the time it was applied, if any
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// Up applies all pending migrations in order and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	unlock, err := m.acquireLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(pending) == 0 {
+		m.logger.Info("Database schema is up to date")
+		return 0, nil
+	}
+
+	applied := 0
+	for _, migration := range pending {
+		entry := m.logger.WithFields(logrus.Fields{
+			"version": migration.Version,
+			"name":    migration.Name,
+		})
+		entry.Info("Applying migration")
+
+		query := `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+		ok, err := m.step(ctx, migration.Version, false, func(tx execer) error {
+			if err := m.exec(ctx, tx, migration.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, m.rebind(query), migration.Version, migration.Name, migration.Checksum); err != nil {
+				return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+		if !ok {
+			entry.Info("Migration was applied by another process")
+			continue
+		}
+
+		entry.Info("Migration applied")
+		applied++
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be greater than 0")
+	}
+
+	unlock, err := m.acquireLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Refuse to roll back from a schema we don't know
+	if _, err := pendingMigrations(m.migrations, applied); err != nil {
+		return 0, err
+	}
+
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	rolledBack := 0
+	for i := len(applied) - 1; i >= 0 && rolledBack < steps; i-- {
+		migration := byVersion[applied[i].Version]
+		entry := m.logger.WithFields(logrus.Fields{
+			"version": migration.Version,
+			"name":    migration.Name,
+		})
+		entry.Info("Rolling back migration")
+
+		ok, err := m.step(ctx, migration.Version, true, func(tx execer) error {
+			if err := m.exec(ctx, tx, migration.Down); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, m.rebind(`DELETE FROM schema_migrations WHERE version = ?`), migration.Version); err != nil {
+				return fmt.Errorf("failed to unrecord migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return rolledBack, err
+		}
+		if !ok {
+			entry.Info("Migration was rolled back by another process")
+			continue
+		}
+
+		entry.Info("Migration rolled back")
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}
+
+// acquireLock takes the migration lock of the driver. The schema_migrations
+// table is created first so that the lock can rely on it.
+func (m *Migrator) acquireLock(ctx context.Context) (unlock func(), err error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	if m.lock == nil {
+		return func() {}, nil
+	}
+
+	m.logger.Debug("Waiting for the schema migration lock")
+	if unlock, err = m.lock(ctx); err != nil {
+		return nil, fmt.Errorf("failed to lock schema migrations: %w", err)
+	}
+	return unlock, nil
+}
+
+// step runs fn for the migration of version. On transactional drivers fn runs
+// in a transaction that first checks whether the migration is still applied
+// (applied set) or still pending, and step returns false without running fn if
+// another process got there first.
+func (m *Migrator) step(ctx context.Context, version int, applied bool, fn func(tx execer) error) (bool, error) {
+	if !m.transactional {
+		return true, fn(m.db)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, m.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	if (count > 0) != applied {
+		return false, nil
+	}
+
+	if err := fn(tx); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", version, err)
+	}
+	return true, nil
+}
+
+// exec runs every statement of a migration script in order
+func (m *Migrator) exec(ctx context.Context, db execer, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("statement failed: %w\n%s", err, statement)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"nasa-data-hub-etl/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	for _, dir := range []string{"migrations/vertica", "migrations/postgres", "migrations/sqlite"} {
+		t.Run(dir, func(t *testing.T) {
+			migrations, err := LoadMigrations(embeddedMigrations, dir)
+			if err != nil {
+				t.Fatalf("LoadMigrations() error = %v", err)
+			}
+
+			if len(migrations) == 0 {
+				t.Fatal("LoadMigrations() returned no migrations")
+			}
+
+			for i, migration := range migrations {
+				if migration.Version != i+1 {
+					t.Errorf("migration %d has version %d", i, migration.Version)
+				}
+				if len(migration.Checksum) != 64 {
+					t.Errorf("migration %d has invalid checksum %q", migration.Version, migration.Checksum)
+				}
+				if len(splitStatements(migration.Up)) == 0 {
+					t.Errorf("migration %d has an empty up script", migration.Version)
+				}
+				if len(splitStatements(migration.Down)) == 0 {
+					t.Errorf("migration %d has an empty down script", migration.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "missing down script",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+		},
+		{
+			name: "version gap",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+				"m/0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+				"m/0003_more.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+				"m/0003_more.down.sql": {Data: []byte("DROP TABLE b;")},
+			},
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"m/init.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+				"m/0001_other.down.sql": {Data: []byte("DROP TABLE a;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.files, "m"); err == nil {
+				t.Error("LoadMigrations() should fail")
+			}
+		})
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	known := []Migration{
+		{Version: 1, Name: "init", Checksum: "aaa"},
+		{Version: 2, Name: "more", Checksum: "bbb"},
+	}
+
+	tests := []struct {
+		name        string
+		applied     []AppliedMigration
+		wantPending int
+		wantErr     bool
+		wantAhead   bool
+	}{
+		{
+			name:        "fresh database",
+			applied:     nil,
+			wantPending: 2,
+		},
+		{
+			name:        "partially migrated",
+			applied:     []AppliedMigration{{Version: 1, Name: "init", Checksum: "aaa"}},
+			wantPending: 1,
+		},
+		{
+			name: "up to date",
+			applied: []AppliedMigration{
+				{Version: 1, Name: "init", Checksum: "aaa"},
+				{Version: 2, Name: "more", Checksum: "bbb"},
+			},
+			wantPending: 0,
+		},
+		{
+			name: "schema ahead of binary",
+			applied: []AppliedMigration{
+				{Version: 1, Name: "init", Checksum: "aaa"},
+				{Version: 2, Name: "more", Checksum: "bbb"},
+				{Version: 3, Name: "future", Checksum: "ccc"},
+			},
+			wantErr:   true,
+			wantAhead: true,
+		},
+		{
+			name:    "checksum mismatch",
+			applied: []AppliedMigration{{Version: 1, Name: "init", Checksum: "changed"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, err := pendingMigrations(known, tt.applied)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pendingMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantAhead && !errors.Is(err, ErrSchemaAhead) {
+				t.Errorf("pendingMigrations() error = %v, want ErrSchemaAhead", err)
+			}
+			if !tt.wantErr && len(pending) != tt.wantPending {
+				t.Errorf("pendingMigrations() returned %d pending, want %d", len(pending), tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- leading comment; with a semicolon
+CREATE TABLE a (id INT);
+
+INSERT INTO a VALUES ('x;y'); -- trailing comment
+UPDATE a SET id = 1
+`
+
+	statements := splitStatements(script)
+
+	want := []string{
+		"CREATE TABLE a (id INT)",
+		"INSERT INTO a VALUES ('x;y')",
+		"UPDATE a SET id = 1",
+	}
+
+	if len(statements) != len(want) {
+		t.Fatalf("splitStatements() returned %d statements, want %d: %q", len(statements), len(want), statements)
+	}
+	for i := range want {
+		if statements[i] != want[i] {
+			t.Errorf("statement %d = %q, want %q", i, statements[i], want[i])
+		}
+	}
+}
+
+// openTestSQLiteFile opens the SQLite file at path without migrating it
+func openTestSQLiteFile(t *testing.T, path string) *SQLiteDB {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	store, err := Open(&config.DatabaseConfig{Driver: config.DriverSQLite, Path: path}, logger)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store.(*SQLiteDB)
+}
+
+func TestMigrator_UpRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLiteFile(t, filepath.Join(t.TempDir(), "test.gpkg"))
+
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"m/0001_init.up.sql":     {Data: []byte("CREATE TABLE a (id INT);")},
+		"m/0001_init.down.sql":   {Data: []byte("DROP TABLE a;")},
+		"m/0002_broken.up.sql":   {Data: []byte("CREATE TABLE b (id INT); INSERT INTO missing VALUES (1);")},
+		"m/0002_broken.down.sql": {Data: []byte("DROP TABLE b;")},
+	}, "m")
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	migrator := NewMigrator(s.db, migrations, s.logger)
+	migrator.transactional = true
+
+	applied, err := migrator.Up(ctx)
+	if err == nil || applied != 1 {
+		t.Fatalf("Up() = %d, %v, want 1 and an error", applied, err)
+	}
+
+	var tables int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'b'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Error("the failed migration left table b behind")
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("Pending() = %v, want the failed migration", pending)
+	}
+}
+
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gpkg")
+
+	// Each store stands for a replica with its own connection
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	counts := make([]int, 3)
+	errs := make([]error, len(counts))
+	total := 0
+	for i := range counts {
+		migrator, err := openTestSQLiteFile(t, path).Migrator()
+		if err != nil {
+			t.Fatalf("Migrator() error = %v", err)
+		}
+		total = len(migrator.migrations)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			counts[i], errs[i] = migrator.Up(context.Background())
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	applied := 0
+	for i := range counts {
+		if errs[i] != nil {
+			t.Errorf("Up() error = %v", errs[i])
+		}
+		applied += counts[i]
+	}
+	if applied != total {
+		t.Errorf("Up() applied %d migrations in total, want each of %d once", applied, total)
+	}
+}
+
+func TestInitializeDatabase_ReviveRequiresCurrentSchema(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLiteFile(t, filepath.Join(t.TempDir(), "test.gpkg"))
+
+	if err := s.InitializeDatabase(ctx, InitModeRevive); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("InitializeDatabase(Revive) on an empty database error = %v, want ErrSchemaBehind", err)
+	}
+
+	if err := s.InitializeDatabase(ctx, InitModeCreate); err != nil {
+		t.Fatalf("InitializeDatabase(Create) error = %v", err)
+	}
+	if err := s.InitializeDatabase(ctx, InitModeRevive); err != nil {
+		t.Errorf("InitializeDatabase(Revive) on a current schema error = %v", err)
+	}
+}
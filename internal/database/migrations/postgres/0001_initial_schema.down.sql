@@ -0,0 +1,9 @@
+-- The postgis extension is left installed, other schemas may use it
+DROP TABLE IF EXISTS etl_backfill_chunks;
+DROP TABLE IF EXISTS etl_watermarks;
+DROP TABLE IF EXISTS etl_runs;
+DROP TABLE IF EXISTS event_categories;
+DROP TABLE IF EXISTS event_sources;
+DROP TABLE IF EXISTS event_geometries;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS categories;
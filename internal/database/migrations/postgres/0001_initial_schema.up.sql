@@ -0,0 +1,86 @@
+-- Initial PostgreSQL schema, equivalent to the Vertica schema with geometries
+-- stored as PostGIS geometry
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(64) PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    link VARCHAR(500),
+    description TEXT,
+    layers VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id VARCHAR(50) PRIMARY KEY,
+    title VARCHAR(500) NOT NULL,
+    description TEXT,
+    link VARCHAR(500),
+    categories TEXT,
+    sources TEXT,
+    geometry TEXT,
+    closed VARCHAR(50),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS event_geometries (
+    event_id VARCHAR(50) NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    observed_at TIMESTAMPTZ,
+    geometry_type VARCHAR(20) NOT NULL,
+    longitude DOUBLE PRECISION,
+    latitude DOUBLE PRECISION,
+    magnitude_value DOUBLE PRECISION,
+    magnitude_unit VARCHAR(50),
+    magnitude_description VARCHAR(500),
+    coordinates TEXT,
+    geom geometry(Geometry, 4326),
+    PRIMARY KEY (event_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS event_geometries_geom_idx ON event_geometries USING GIST (geom);
+CREATE INDEX IF NOT EXISTS event_geometries_observed_at_idx ON event_geometries (observed_at);
+
+CREATE TABLE IF NOT EXISTS event_sources (
+    event_id VARCHAR(50) NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    source_id VARCHAR(100) NOT NULL,
+    url VARCHAR(2000),
+    PRIMARY KEY (event_id, source_id)
+);
+
+CREATE TABLE IF NOT EXISTS event_categories (
+    event_id VARCHAR(50) NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    category_id VARCHAR(64) NOT NULL,
+    PRIMARY KEY (event_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS event_categories_category_id_idx ON event_categories (category_id);
+
+CREATE TABLE IF NOT EXISTS etl_runs (
+    id BIGINT PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL,
+    events_processed INTEGER DEFAULT 0,
+    categories_processed INTEGER DEFAULT 0,
+    error_message TEXT
+);
+
+CREATE TABLE IF NOT EXISTS etl_watermarks (
+    source VARCHAR(100) PRIMARY KEY,
+    watermark TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS etl_backfill_chunks (
+    chunk_start DATE NOT NULL,
+    chunk_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    events_loaded INTEGER DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (chunk_start, chunk_end)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE etl_runs
+    DROP COLUMN IF EXISTS config_hash,
+    DROP COLUMN IF EXISTS git_commit,
+    DROP COLUMN IF EXISTS app_version,
+    DROP COLUMN IF EXISTS bytes_downloaded,
+    DROP COLUMN IF EXISTS api_requests,
+    DROP COLUMN IF EXISTS load_duration_ms,
+    DROP COLUMN IF EXISTS transform_duration_ms,
+    DROP COLUMN IF EXISTS extract_duration_ms;
+
+ALTER TABLE etl_runs ALTER COLUMN id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS etl_runs_id_seq;
@@ -0,0 +1,16 @@
+-- Run ids come from a sequence instead of the start time in seconds, which
+-- collided when two runs started within the same second. Legacy ids are Unix
+-- timestamps, far above the values the sequence will reach.
+CREATE SEQUENCE IF NOT EXISTS etl_runs_id_seq OWNED BY etl_runs.id;
+ALTER TABLE etl_runs ALTER COLUMN id SET DEFAULT nextval('etl_runs_id_seq');
+
+-- Phase timings, API usage and the binary and configuration of each run
+ALTER TABLE etl_runs
+    ADD COLUMN IF NOT EXISTS extract_duration_ms BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS transform_duration_ms BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS load_duration_ms BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS api_requests INTEGER DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS bytes_downloaded BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS app_version VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS git_commit VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS config_hash VARCHAR(64);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS etl_backfill_chunks;
+DROP TABLE IF EXISTS etl_watermarks;
+DROP TABLE IF EXISTS etl_runs;
+DROP TABLE IF EXISTS event_categories;
+DROP TABLE IF EXISTS event_sources;
+DROP TABLE IF EXISTS event_geometries;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS gpkg_geometry_columns;
+DROP TABLE IF EXISTS gpkg_contents;
+DROP TABLE IF EXISTS gpkg_spatial_ref_sys;
+PRAGMA application_id = 0;
+PRAGMA user_version = 0;
@@ -0,0 +1,149 @@
+-- Initial SQLite schema. The file is a GeoPackage 1.3 so that QGIS and other
+-- GIS tools can open it directly: event_geometries is registered as a feature
+-- table with GeoPackage geometry blobs, the other tables as attribute tables.
+PRAGMA application_id = 1196444487;
+PRAGMA user_version = 10300;
+
+CREATE TABLE IF NOT EXISTS gpkg_spatial_ref_sys (
+    srs_name TEXT NOT NULL,
+    srs_id INTEGER PRIMARY KEY,
+    organization TEXT NOT NULL,
+    organization_coordsys_id INTEGER NOT NULL,
+    definition TEXT NOT NULL,
+    description TEXT
+);
+
+INSERT OR IGNORE INTO gpkg_spatial_ref_sys (srs_name, srs_id, organization, organization_coordsys_id, definition, description)
+VALUES ('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system');
+
+INSERT OR IGNORE INTO gpkg_spatial_ref_sys (srs_name, srs_id, organization, organization_coordsys_id, definition, description)
+VALUES ('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system');
+
+INSERT OR IGNORE INTO gpkg_spatial_ref_sys (srs_name, srs_id, organization, organization_coordsys_id, definition, description)
+VALUES ('WGS 84 geodetic', 4326, 'EPSG', 4326,
+    'GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]',
+    'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid');
+
+CREATE TABLE IF NOT EXISTS gpkg_contents (
+    table_name TEXT NOT NULL PRIMARY KEY,
+    data_type TEXT NOT NULL,
+    identifier TEXT UNIQUE,
+    description TEXT DEFAULT '',
+    last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    min_x DOUBLE,
+    min_y DOUBLE,
+    max_x DOUBLE,
+    max_y DOUBLE,
+    srs_id INTEGER,
+    CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id)
+);
+
+CREATE TABLE IF NOT EXISTS gpkg_geometry_columns (
+    table_name TEXT NOT NULL,
+    column_name TEXT NOT NULL,
+    geometry_type_name TEXT NOT NULL,
+    srs_id INTEGER NOT NULL,
+    z TINYINT NOT NULL,
+    m TINYINT NOT NULL,
+    CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name),
+    CONSTRAINT uk_gc_table_name UNIQUE (table_name),
+    CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents (table_name),
+    CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id)
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    link TEXT,
+    description TEXT,
+    layers TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT,
+    link TEXT,
+    categories TEXT,
+    sources TEXT,
+    geometry TEXT,
+    closed TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- GeoPackage feature tables need an integer primary key
+CREATE TABLE IF NOT EXISTS event_geometries (
+    fid INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id TEXT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    observed_at TIMESTAMP,
+    geometry_type TEXT NOT NULL,
+    longitude DOUBLE,
+    latitude DOUBLE,
+    magnitude_value DOUBLE,
+    magnitude_unit TEXT,
+    magnitude_description TEXT,
+    coordinates TEXT,
+    geom GEOMETRY,
+    UNIQUE (event_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS event_sources (
+    event_id TEXT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    source_id TEXT NOT NULL,
+    url TEXT,
+    PRIMARY KEY (event_id, source_id)
+);
+
+CREATE TABLE IF NOT EXISTS event_categories (
+    event_id TEXT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    category_id TEXT NOT NULL,
+    PRIMARY KEY (event_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS event_categories_category_id_idx ON event_categories (category_id);
+
+CREATE TABLE IF NOT EXISTS etl_runs (
+    id INTEGER PRIMARY KEY,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    status TEXT NOT NULL,
+    events_processed INTEGER DEFAULT 0,
+    categories_processed INTEGER DEFAULT 0,
+    error_message TEXT
+);
+
+CREATE TABLE IF NOT EXISTS etl_watermarks (
+    source TEXT PRIMARY KEY,
+    watermark TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS etl_backfill_chunks (
+    chunk_start DATE NOT NULL,
+    chunk_end DATE NOT NULL,
+    status TEXT NOT NULL,
+    events_loaded INTEGER DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (chunk_start, chunk_end)
+);
+
+INSERT OR IGNORE INTO gpkg_contents (table_name, data_type, identifier, description, srs_id)
+VALUES ('event_geometries', 'features', 'EONET event geometries', 'One row per geometry observation of an EONET event', 4326);
+
+INSERT OR IGNORE INTO gpkg_geometry_columns (table_name, column_name, geometry_type_name, srs_id, z, m)
+VALUES ('event_geometries', 'geom', 'GEOMETRY', 4326, 0, 0);
+
+INSERT OR IGNORE INTO gpkg_contents (table_name, data_type, identifier, description)
+VALUES ('events', 'attributes', 'EONET events', 'Natural events from NASA EONET');
+
+INSERT OR IGNORE INTO gpkg_contents (table_name, data_type, identifier, description)
+VALUES ('categories', 'attributes', 'EONET categories', 'EONET event categories');
+
+INSERT OR IGNORE INTO gpkg_contents (table_name, data_type, identifier, description)
+VALUES ('etl_runs', 'attributes', 'ETL runs', 'History of ETL pipeline runs');
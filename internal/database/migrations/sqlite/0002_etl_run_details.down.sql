@@ -0,0 +1,8 @@
+ALTER TABLE etl_runs DROP COLUMN config_hash;
+ALTER TABLE etl_runs DROP COLUMN git_commit;
+ALTER TABLE etl_runs DROP COLUMN app_version;
+ALTER TABLE etl_runs DROP COLUMN bytes_downloaded;
+ALTER TABLE etl_runs DROP COLUMN api_requests;
+ALTER TABLE etl_runs DROP COLUMN load_duration_ms;
+ALTER TABLE etl_runs DROP COLUMN transform_duration_ms;
+ALTER TABLE etl_runs DROP COLUMN extract_duration_ms;
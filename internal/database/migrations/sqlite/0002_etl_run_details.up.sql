@@ -0,0 +1,11 @@
+-- Run ids are assigned by SQLite (etl_runs.id is the rowid) instead of the
+-- start time in seconds, which collided when two runs started within the same
+-- second. Phase timings, API usage and the binary and configuration of each run.
+ALTER TABLE etl_runs ADD COLUMN extract_duration_ms INTEGER DEFAULT 0;
+ALTER TABLE etl_runs ADD COLUMN transform_duration_ms INTEGER DEFAULT 0;
+ALTER TABLE etl_runs ADD COLUMN load_duration_ms INTEGER DEFAULT 0;
+ALTER TABLE etl_runs ADD COLUMN api_requests INTEGER DEFAULT 0;
+ALTER TABLE etl_runs ADD COLUMN bytes_downloaded INTEGER DEFAULT 0;
+ALTER TABLE etl_runs ADD COLUMN app_version TEXT;
+ALTER TABLE etl_runs ADD COLUMN git_commit TEXT;
+ALTER TABLE etl_runs ADD COLUMN config_hash TEXT;
@@ -0,0 +1 @@
+ALTER TABLE etl_runs DROP COLUMN heartbeat_at;
@@ -0,0 +1,9 @@
+-- Leases that let one process at a time run the pipeline. A lease is held by
+-- owner until expires_at and renewed while the owner is alive.
+CREATE TABLE IF NOT EXISTS etl_locks (
+    name TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    acquired_at TIMESTAMP,
+    renewed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE event_geometries DROP COLUMN max_lat;
+ALTER TABLE event_geometries DROP COLUMN max_lon;
+ALTER TABLE event_geometries DROP COLUMN min_lat;
+ALTER TABLE event_geometries DROP COLUMN min_lon;
@@ -0,0 +1,11 @@
+-- Bounding box of each geometry so that bbox filters match polygons that
+-- reach into the box. Rows loaded before are filled from their centroid until
+-- their event is loaded again.
+ALTER TABLE event_geometries ADD COLUMN min_lon DOUBLE;
+ALTER TABLE event_geometries ADD COLUMN min_lat DOUBLE;
+ALTER TABLE event_geometries ADD COLUMN max_lon DOUBLE;
+ALTER TABLE event_geometries ADD COLUMN max_lat DOUBLE;
+
+UPDATE event_geometries
+SET min_lon = longitude, min_lat = latitude, max_lon = longitude, max_lat = latitude
+WHERE min_lon IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS etl_runs CASCADE;
+DROP TABLE IF EXISTS events CASCADE;
+DROP TABLE IF EXISTS categories CASCADE;
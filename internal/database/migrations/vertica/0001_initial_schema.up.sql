@@ -0,0 +1,41 @@
+-- Initial schema. Tables use IF NOT EXISTS so that databases created before
+-- schema migrations existed are adopted as they are.
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    link VARCHAR(500),
+    description VARCHAR(10000),
+    layers VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id VARCHAR(50) PRIMARY KEY,
+    title VARCHAR(500) NOT NULL,
+    description VARCHAR(10000),
+    link VARCHAR(500),
+    categories VARCHAR(10000),
+    sources VARCHAR(10000),
+    geometry VARCHAR(10000),
+    closed VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The old -db-init=Create path created events with geometries/date instead of
+-- geometry/closed, which the loader never wrote to
+ALTER TABLE events ADD COLUMN IF NOT EXISTS geometry VARCHAR(10000);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS closed VARCHAR(50);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS link VARCHAR(500);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS layers VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS etl_runs (
+    id BIGINT PRIMARY KEY,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL,
+    events_processed INTEGER DEFAULT 0,
+    categories_processed INTEGER DEFAULT 0,
+    error_message VARCHAR(10000)
+);
@@ -0,0 +1,39 @@
+-- Restore integer category ids. Categories without an EONET v2.1 number are
+-- dropped; category arrays on events keep their string ids.
+DROP TABLE IF EXISTS categories_v2 CASCADE;
+
+CREATE TABLE categories_v2 (
+    id INTEGER PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    link VARCHAR(500),
+    description VARCHAR(10000),
+    layers VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO categories_v2 (id, title, link, description, layers, created_at, updated_at)
+SELECT
+    CASE id
+        WHEN 'drought' THEN 6
+        WHEN 'dustHaze' THEN 7
+        WHEN 'wildfires' THEN 8
+        WHEN 'floods' THEN 9
+        WHEN 'severeStorms' THEN 10
+        WHEN 'volcanoes' THEN 12
+        WHEN 'waterColor' THEN 13
+        WHEN 'landslides' THEN 14
+        WHEN 'seaLakeIce' THEN 15
+        WHEN 'earthquakes' THEN 16
+        WHEN 'snow' THEN 17
+        WHEN 'tempExtremes' THEN 18
+        WHEN 'manmade' THEN 19
+    END,
+    title, link, description, layers, created_at, updated_at
+FROM categories
+WHERE id IN ('drought', 'dustHaze', 'wildfires', 'floods', 'severeStorms', 'volcanoes', 'waterColor',
+             'landslides', 'seaLakeIce', 'earthquakes', 'snow', 'tempExtremes', 'manmade');
+
+DROP TABLE categories CASCADE;
+
+ALTER TABLE categories_v2 RENAME TO categories;
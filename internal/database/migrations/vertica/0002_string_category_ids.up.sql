@@ -0,0 +1,68 @@
+-- EONET v3 identifies categories by strings such as 'wildfires'. Rebuild the
+-- categories table with a VARCHAR key and map the numeric ids of EONET v2.1 to
+-- their v3 names. Rows with id 0 are v3 categories collapsed by the old integer
+-- conversion; they can't be recovered and are reloaded by the next run.
+DROP TABLE IF EXISTS categories_v3 CASCADE;
+
+CREATE TABLE categories_v3 (
+    id VARCHAR(64) PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    link VARCHAR(500),
+    description VARCHAR(10000),
+    layers VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO categories_v3 (id, title, link, description, layers, created_at, updated_at)
+SELECT
+    CASE CAST(id AS VARCHAR(64))
+        WHEN '6' THEN 'drought'
+        WHEN '7' THEN 'dustHaze'
+        WHEN '8' THEN 'wildfires'
+        WHEN '9' THEN 'floods'
+        WHEN '10' THEN 'severeStorms'
+        WHEN '12' THEN 'volcanoes'
+        WHEN '13' THEN 'waterColor'
+        WHEN '14' THEN 'landslides'
+        WHEN '15' THEN 'seaLakeIce'
+        WHEN '16' THEN 'earthquakes'
+        WHEN '17' THEN 'snow'
+        WHEN '18' THEN 'tempExtremes'
+        WHEN '19' THEN 'manmade'
+        ELSE CAST(id AS VARCHAR(64))
+    END,
+    title, link, description, layers, created_at, updated_at
+FROM categories
+WHERE CAST(id AS VARCHAR(64)) <> '0';
+
+DROP TABLE categories CASCADE;
+
+ALTER TABLE categories_v3 RENAME TO categories;
+
+-- Rewrite numeric category arrays stored on events, e.g. [8,0] becomes
+-- ["wildfires"]. The patterns only match bare numbers directly after '[' or
+-- ',' so arrays that already hold strings are left untouched.
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])0(?=[,\]])', '')
+WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+
+UPDATE events SET categories = REGEXP_REPLACE(REGEXP_REPLACE(REGEXP_REPLACE(categories, ',{2,}', ','), '\[,', '['), ',\]', ']')
+WHERE REGEXP_LIKE(categories, ',,|\[,|,\]');
+
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])6(?=[,\]])', '"drought"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])7(?=[,\]])', '"dustHaze"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])8(?=[,\]])', '"wildfires"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])9(?=[,\]])', '"floods"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])10(?=[,\]])', '"severeStorms"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])12(?=[,\]])', '"volcanoes"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])13(?=[,\]])', '"waterColor"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])14(?=[,\]])', '"landslides"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])15(?=[,\]])', '"seaLakeIce"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])16(?=[,\]])', '"earthquakes"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])17(?=[,\]])', '"snow"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])18(?=[,\]])', '"tempExtremes"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])19(?=[,\]])', '"manmade"') WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
+
+-- Any other number is kept as a string id
+UPDATE events SET categories = REGEXP_REPLACE(categories, '(?<=[\[,])([0-9]+)(?=[,\]])', '"\1"')
+WHERE REGEXP_LIKE(categories, '[\[,][0-9]');
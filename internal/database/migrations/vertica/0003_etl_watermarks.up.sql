@@ -0,0 +1,6 @@
+-- High-water marks for incremental extraction, one row per source
+CREATE TABLE IF NOT EXISTS etl_watermarks (
+    source VARCHAR(100) PRIMARY KEY,
+    watermark TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
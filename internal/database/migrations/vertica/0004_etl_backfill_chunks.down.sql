@@ -0,0 +1 @@
+DROP TABLE IF EXISTS etl_backfill_chunks CASCADE;
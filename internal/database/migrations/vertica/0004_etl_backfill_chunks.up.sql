@@ -0,0 +1,11 @@
+-- Progress of historical backfills, one row per date range chunk
+CREATE TABLE IF NOT EXISTS etl_backfill_chunks (
+    chunk_start DATE NOT NULL,
+    chunk_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    events_loaded INTEGER DEFAULT 0,
+    error_message VARCHAR(10000),
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (chunk_start, chunk_end)
+);
@@ -0,0 +1,4 @@
+-- The widened events columns are kept, narrowing them could truncate data
+DROP TABLE IF EXISTS event_categories CASCADE;
+DROP TABLE IF EXISTS event_sources CASCADE;
+DROP TABLE IF EXISTS event_geometries CASCADE;
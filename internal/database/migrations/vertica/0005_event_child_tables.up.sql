@@ -0,0 +1,31 @@
+-- Normalized copies of the JSON columns of events so they can be joined and
+-- filtered on. Rows are replaced together with their parent event.
+CREATE TABLE IF NOT EXISTS event_geometries (
+    event_id VARCHAR(50) NOT NULL,
+    seq INTEGER NOT NULL,
+    observed_at TIMESTAMP,
+    geometry_type VARCHAR(20) NOT NULL,
+    longitude FLOAT,
+    latitude FLOAT,
+    magnitude_value FLOAT,
+    magnitude_unit VARCHAR(50),
+    coordinates VARCHAR(65000),
+    PRIMARY KEY (event_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS event_sources (
+    event_id VARCHAR(50) NOT NULL,
+    source_id VARCHAR(100) NOT NULL,
+    url VARCHAR(2000),
+    PRIMARY KEY (event_id, source_id)
+);
+
+CREATE TABLE IF NOT EXISTS event_categories (
+    event_id VARCHAR(50) NOT NULL,
+    category_id VARCHAR(64) NOT NULL,
+    PRIMARY KEY (event_id, category_id)
+);
+
+-- Long wildfire tracks overflowed VARCHAR(10000)
+ALTER TABLE events ALTER COLUMN geometry SET DATA TYPE VARCHAR(65000);
+ALTER TABLE events ALTER COLUMN sources SET DATA TYPE VARCHAR(65000);
@@ -0,0 +1 @@
+ALTER TABLE event_geometries DROP COLUMN IF EXISTS magnitude_description;
@@ -0,0 +1,2 @@
+-- Description of the magnitude, e.g. "Maximum Sustained Wind Speed"
+ALTER TABLE event_geometries ADD COLUMN IF NOT EXISTS magnitude_description VARCHAR(500);
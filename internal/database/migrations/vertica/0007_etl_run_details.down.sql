@@ -0,0 +1,10 @@
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS config_hash;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS git_commit;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS app_version;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS bytes_downloaded;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS api_requests;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS load_duration_ms;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS transform_duration_ms;
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS extract_duration_ms;
+
+DROP SEQUENCE IF EXISTS etl_runs_id_seq;
@@ -0,0 +1 @@
+ALTER TABLE etl_runs DROP COLUMN IF EXISTS heartbeat_at;
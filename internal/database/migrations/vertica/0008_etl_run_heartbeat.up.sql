@@ -0,0 +1,2 @@
+-- Updated while a run executes, runs whose heartbeat stopped are marked abandoned
+ALTER TABLE etl_runs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP;
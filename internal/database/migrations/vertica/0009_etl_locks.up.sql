@@ -0,0 +1,9 @@
+-- Leases that let one replica at a time run the pipeline. A lease is held by
+-- owner until expires_at and renewed while the owner is alive.
+CREATE TABLE IF NOT EXISTS etl_locks (
+    name VARCHAR(100) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMP,
+    renewed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE event_geometries DROP COLUMN IF EXISTS max_lat;
+ALTER TABLE event_geometries DROP COLUMN IF EXISTS max_lon;
+ALTER TABLE event_geometries DROP COLUMN IF EXISTS min_lat;
+ALTER TABLE event_geometries DROP COLUMN IF EXISTS min_lon;
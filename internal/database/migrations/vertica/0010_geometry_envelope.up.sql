@@ -0,0 +1,11 @@
+-- Bounding box of each geometry so that bbox filters match polygons that
+-- reach into the box. Rows loaded before are filled from their centroid until
+-- their event is loaded again.
+ALTER TABLE event_geometries ADD COLUMN IF NOT EXISTS min_lon FLOAT;
+ALTER TABLE event_geometries ADD COLUMN IF NOT EXISTS min_lat FLOAT;
+ALTER TABLE event_geometries ADD COLUMN IF NOT EXISTS max_lon FLOAT;
+ALTER TABLE event_geometries ADD COLUMN IF NOT EXISTS max_lat FLOAT;
+
+UPDATE event_geometries
+SET min_lon = longitude, min_lat = latitude, max_lon = longitude, max_lat = latitude
+WHERE min_lon IS NULL;
@@ -0,0 +1,612 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/pkg/models"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+)
+
+var _ Store = (*PostgresDB)(nil)
+
+// PostgresDB handles database operations for PostgreSQL with PostGIS
+type PostgresDB struct {
+	db     *sql.DB
+	config *config.DatabaseConfig
+	logger *logrus.Logger
+}
+
+// NewPostgresDB creates a new PostgreSQL connection
+func NewPostgresDB(cfg *config.DatabaseConfig, logger *logrus.Logger) (*PostgresDB, error) {
+	db, err := sql.Open("pgx", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// Set connection pool settings
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return &PostgresDB{
+		db:     db,
+		config: cfg,
+		logger: logger,
+	}, nil
+}
+
+// UpsertEvents upserts a batch of events with INSERT ... ON CONFLICT. Rows
+// whose content is unchanged are not touched, child rows are replaced for
+// inserted and updated events.
+func (p *PostgresDB) UpsertEvents(ctx context.Context, events []*models.EventRecord) (UpsertResult, error) {
+	if len(events) == 0 {
+		return UpsertResult{}, nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			p.logger.WithError(err).Error("Failed to rollback transaction")
+		}
+	}()
+
+	query := `
+		INSERT INTO events AS t (id, title, description, link, categories, sources, geometry, closed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			link = EXCLUDED.link,
+			categories = EXCLUDED.categories,
+			sources = EXCLUDED.sources,
+			geometry = EXCLUDED.geometry,
+			closed = EXCLUDED.closed,
+			updated_at = now()
+		WHERE (t.title, t.description, t.link, t.categories, t.sources, t.geometry, t.closed)
+			IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.description, EXCLUDED.link, EXCLUDED.categories, EXCLUDED.sources, EXCLUDED.geometry, EXCLUDED.closed)
+		RETURNING (xmax = 0) AS inserted
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var result UpsertResult
+	for _, event := range dedupeEvents(events) {
+		var inserted bool
+		err := stmt.QueryRowContext(ctx,
+			event.ID,
+			event.Title,
+			event.Description,
+			event.Link,
+			event.Categories,
+			event.Sources,
+			event.Geometry,
+			event.Closed,
+		).Scan(&inserted)
+		switch {
+		case err == sql.ErrNoRows:
+			result.Unchanged++
+			continue
+		case err != nil:
+			return UpsertResult{}, fmt.Errorf("failed to upsert event %s: %w", event.ID, err)
+		case inserted:
+			result.Inserted++
+		default:
+			result.Updated++
+		}
+
+		if err := p.replaceEventChildren(ctx, tx, event); err != nil {
+			return UpsertResult{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+	}).Info("Successfully upserted events")
+	return result, nil
+}
+
+// replaceEventChildren deletes the child rows of an event and inserts the current ones
+func (p *PostgresDB) replaceEventChildren(ctx context.Context, tx *sql.Tx, event *models.EventRecord) error {
+	for _, table := range []string{"event_geometries", "event_sources", "event_categories"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE event_id = $1`, table), event.ID); err != nil {
+			return fmt.Errorf("failed to delete child rows of event %s: %w", event.ID, err)
+		}
+	}
+
+	for _, g := range event.Geometries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO event_geometries (event_id, seq, observed_at, geometry_type, longitude, latitude,
+				magnitude_value, magnitude_unit, magnitude_description, coordinates, geom)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, ST_SetSRID(ST_GeomFromGeoJSON($11::text), 4326))
+		`,
+			event.ID,
+			g.Seq,
+			g.ObservedAt.UTC(),
+			g.Type,
+			g.Longitude,
+			g.Latitude,
+			g.MagnitudeValue,
+			g.MagnitudeUnit,
+			g.MagnitudeDescription,
+			g.Coordinates,
+			geometryGeoJSON(g),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert geometry %d of event %s: %w", g.Seq, event.ID, err)
+		}
+	}
+
+	for _, source := range event.SourceLinks {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO event_sources (event_id, source_id, url) VALUES ($1, $2, $3)`,
+			event.ID, source.SourceID, source.URL); err != nil {
+			return fmt.Errorf("failed to insert source %s of event %s: %w", source.SourceID, event.ID, err)
+		}
+	}
+
+	for _, categoryID := range event.CategoryIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO event_categories (event_id, category_id) VALUES ($1, $2)`,
+			event.ID, categoryID); err != nil {
+			return fmt.Errorf("failed to insert category %s of event %s: %w", categoryID, event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// geometryGeoJSON returns the GeoJSON geometry object of a geometry row, or
+// nil if it has no coordinates
+func geometryGeoJSON(g models.EventGeometryRecord) *string {
+	if g.Coordinates == "" || g.Coordinates == "null" {
+		return nil
+	}
+	geoJSON := fmt.Sprintf(`{"type":%q,"coordinates":%s}`, g.Type, g.Coordinates)
+	return &geoJSON
+}
+
+// postgisBBox matches geometries whose bounding box overlaps box through the
+// GIST index on geom
+func postgisBBox(box models.BBox) (string, []any) {
+	return `g.geom && ST_MakeEnvelope(?, ?, ?, ?, 4326)`,
+		[]any{box.MinLon, box.MinLat, box.MaxLon, box.MaxLat}
+}
+
+// UpsertCategories upserts a batch of categories with INSERT ... ON CONFLICT
+func (p *PostgresDB) UpsertCategories(ctx context.Context, categories []*models.CategoryRecord) (UpsertResult, error) {
+	if len(categories) == 0 {
+		return UpsertResult{}, nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			p.logger.WithError(err).Error("Failed to rollback transaction")
+		}
+	}()
+
+	query := `
+		INSERT INTO categories AS t (id, title, link, description, layers)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			title = EXCLUDED.title,
+			link = EXCLUDED.link,
+			description = EXCLUDED.description,
+			layers = EXCLUDED.layers,
+			updated_at = now()
+		WHERE (t.title, t.link, t.description, t.layers)
+			IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.link, EXCLUDED.description, EXCLUDED.layers)
+		RETURNING (xmax = 0) AS inserted
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var result UpsertResult
+	for _, category := range dedupeCategories(categories) {
+		var inserted bool
+		err := stmt.QueryRowContext(ctx,
+			category.ID,
+			category.Title,
+			category.Link,
+			category.Description,
+			category.Layers,
+		).Scan(&inserted)
+		switch {
+		case err == sql.ErrNoRows:
+			result.Unchanged++
+		case err != nil:
+			return UpsertResult{}, fmt.Errorf("failed to upsert category %s: %w", category.ID, err)
+		case inserted:
+			result.Inserted++
+		default:
+			result.Updated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+	}).Info("Successfully upserted categories")
+	return result, nil
+}
+
+// ListEvents returns the events matching filter ordered by id
+func (p *PostgresDB) ListEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar, bbox: postgisBBox}.listEvents(ctx, filter)
+}
+
+// CountEvents counts the events matching filter
+func (p *PostgresDB) CountEvents(ctx context.Context, filter EventFilter) (int64, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar, bbox: postgisBBox}.countEvents(ctx, filter)
+}
+
+// GetEvent returns an event with its child rows, or nil if it does not exist
+func (p *PostgresDB) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar}.getEvent(ctx, id)
+}
+
+// ListCategories returns all categories ordered by id
+func (p *PostgresDB) ListCategories(ctx context.Context) ([]*models.CategoryRecord, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar}.listCategories(ctx)
+}
+
+// StartETLRun records the start of an ETL run with an id from etl_runs_id_seq
+func (p *PostgresDB) StartETLRun(ctx context.Context, run ETLRunStart) (int64, error) {
+	query := `
+		INSERT INTO etl_runs (status, heartbeat_at, app_version, git_commit, config_hash)
+		VALUES ('running', now(), $1, $2, $3)
+		RETURNING id
+	`
+
+	var id int64
+	if err := p.db.QueryRowContext(ctx, query, run.AppVersion, run.GitCommit, run.ConfigHash).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to start ETL run: %w", err)
+	}
+
+	return id, nil
+}
+
+// CompleteETLRun records the completion of an ETL run
+func (p *PostgresDB) CompleteETLRun(ctx context.Context, runID int64, result ETLRunResult) error {
+	query := `
+		UPDATE etl_runs
+		SET completed_at = now(),
+			status = $1,
+			events_processed = $2,
+			categories_processed = $3,
+			error_message = $4,
+			extract_duration_ms = $5,
+			transform_duration_ms = $6,
+			load_duration_ms = $7,
+			api_requests = $8,
+			bytes_downloaded = $9
+		WHERE id = $10
+	`
+
+	_, err := p.db.ExecContext(ctx, query,
+		result.Status,
+		result.EventsProcessed,
+		result.CategoriesProcessed,
+		result.ErrorMessage,
+		result.ExtractDurationMs,
+		result.TransformDurationMs,
+		result.LoadDurationMs,
+		result.APIRequests,
+		result.BytesDownloaded,
+		runID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete ETL run: %w", err)
+	}
+
+	return nil
+}
+
+// HeartbeatETLRun records that a running run is still alive
+func (p *PostgresDB) HeartbeatETLRun(ctx context.Context, runID int64) error {
+	query := `UPDATE etl_runs SET heartbeat_at = now() WHERE id = $1 AND status = 'running'`
+	if _, err := p.db.ExecContext(ctx, query, runID); err != nil {
+		return fmt.Errorf("failed to record ETL run heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// AbandonStaleETLRuns marks running runs without a heartbeat within timeout as abandoned
+func (p *PostgresDB) AbandonStaleETLRuns(ctx context.Context, timeout time.Duration) (int64, error) {
+	query := `
+		UPDATE etl_runs
+		SET status = 'abandoned',
+			completed_at = now(),
+			error_message = $1
+		WHERE status = 'running'
+			AND COALESCE(heartbeat_at, started_at) < now() - make_interval(secs => $2)
+	`
+
+	res, err := p.db.ExecContext(ctx, query, abandonedMessage(timeout), timeout.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to abandon stale ETL runs: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// AcquireLease takes or renews a lease with a conditional upsert, the row
+// lock makes concurrent claims on the same lease serialize
+func (p *PostgresDB) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO etl_locks (name, owner, acquired_at, renewed_at, expires_at)
+		VALUES ($1, $2, now(), now(), now() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE SET
+			acquired_at = CASE WHEN etl_locks.owner = excluded.owner THEN etl_locks.acquired_at ELSE now() END,
+			owner = excluded.owner,
+			renewed_at = now(),
+			expires_at = excluded.expires_at
+		WHERE etl_locks.owner = excluded.owner OR etl_locks.expires_at < now()
+	`
+
+	res, err := p.db.ExecContext(ctx, query, name, owner, ttl.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease expires the lease if owner holds it
+func (p *PostgresDB) ReleaseLease(ctx context.Context, name, owner string) error {
+	query := `UPDATE etl_locks SET expires_at = now() WHERE name = $1 AND owner = $2`
+	if _, err := p.db.ExecContext(ctx, query, name, owner); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// GetLastETLRun returns information about the last ETL run
+func (p *PostgresDB) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
+	query := `SELECT ` + etlRunColumns + ` FROM etl_runs ORDER BY started_at DESC, id DESC LIMIT 1`
+
+	run, err := scanETLRun(p.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No previous runs
+		}
+		return nil, fmt.Errorf("failed to get last ETL run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ListETLRuns returns runs, most recently started first
+func (p *PostgresDB) ListETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar}.listETLRuns(ctx, limit, offset)
+}
+
+// GetETLRun returns a run, or nil if it does not exist
+func (p *PostgresDB) GetETLRun(ctx context.Context, id int64) (*ETLRunInfo, error) {
+	return sqlReader{db: p.db, rebind: rebindDollar}.getETLRun(ctx, id)
+}
+
+// GetWatermark returns the high-water mark of a source, or nil if none was recorded
+func (p *PostgresDB) GetWatermark(ctx context.Context, source string) (*time.Time, error) {
+	var watermark time.Time
+	err := p.db.QueryRowContext(ctx, `SELECT watermark FROM etl_watermarks WHERE source = $1`, source).Scan(&watermark)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get watermark: %w", err)
+	}
+
+	return &watermark, nil
+}
+
+// SetWatermark records the high-water mark of a source
+func (p *PostgresDB) SetWatermark(ctx context.Context, source string, watermark time.Time) error {
+	query := `
+		INSERT INTO etl_watermarks (source, watermark, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (source) DO UPDATE SET watermark = EXCLUDED.watermark, updated_at = now()
+	`
+
+	if _, err := p.db.ExecContext(ctx, query, source, watermark.UTC()); err != nil {
+		return fmt.Errorf("failed to set watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ResetWatermark removes the high-water mark of a source
+func (p *PostgresDB) ResetWatermark(ctx context.Context, source string) error {
+	if _, err := p.db.ExecContext(ctx, `DELETE FROM etl_watermarks WHERE source = $1`, source); err != nil {
+		return fmt.Errorf("failed to reset watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ListBackfillChunks returns the recorded chunks that lie within [from, to)
+func (p *PostgresDB) ListBackfillChunks(ctx context.Context, from, to time.Time) ([]BackfillChunk, error) {
+	query := `
+		SELECT chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at
+		FROM etl_backfill_chunks
+		WHERE chunk_start >= $1 AND chunk_end <= $2
+		ORDER BY chunk_start
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []BackfillChunk
+	for rows.Next() {
+		var chunk BackfillChunk
+		var errorMsg sql.NullString
+		var completedAt sql.NullTime
+		if err := rows.Scan(&chunk.Start, &chunk.End, &chunk.Status, &chunk.EventsLoaded, &errorMsg, &chunk.StartedAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill chunk: %w", err)
+		}
+		if errorMsg.Valid {
+			chunk.ErrorMessage = &errorMsg.String
+		}
+		if completedAt.Valid {
+			chunk.CompletedAt = &completedAt.Time
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, rows.Err()
+}
+
+// SaveBackfillChunk records the progress of a backfill chunk
+func (p *PostgresDB) SaveBackfillChunk(ctx context.Context, chunk *BackfillChunk) error {
+	query := `
+		INSERT INTO etl_backfill_chunks (chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, now(), $6)
+		ON CONFLICT (chunk_start, chunk_end) DO UPDATE SET
+			status = EXCLUDED.status,
+			events_loaded = EXCLUDED.events_loaded,
+			error_message = EXCLUDED.error_message,
+			completed_at = EXCLUDED.completed_at
+	`
+
+	_, err := p.db.ExecContext(ctx, query,
+		chunk.Start.UTC(),
+		chunk.End.UTC(),
+		chunk.Status,
+		chunk.EventsLoaded,
+		chunk.ErrorMessage,
+		chunk.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save backfill chunk: %w", err)
+	}
+
+	return nil
+}
+
+// InitializeDatabase brings the database schema up to date based on mode
+func (p *PostgresDB) InitializeDatabase(ctx context.Context, mode InitMode) error {
+	migrator, err := p.Migrator()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return initializeWithMigrator(ctx, migrator, mode)
+}
+
+// Migrator returns a migrator for the PostgreSQL schema migrations
+func (p *PostgresDB) Migrator() (*Migrator, error) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations/postgres")
+	if err != nil {
+		return nil, err
+	}
+	migrator := NewMigrator(p.db, migrations, p.logger)
+	migrator.rebind = rebindDollar
+	migrator.transactional = true
+	migrator.lock = p.lockMigrations
+	return migrator, nil
+}
+
+// migrationLockKey identifies the advisory lock held while migrating
+const migrationLockKey int64 = 0x4e415341 // "NASA"
+
+// lockMigrations holds a session advisory lock on a dedicated connection, so
+// that replicas starting together migrate one after the other
+func (p *PostgresDB) lockMigrations(ctx context.Context) (func(), error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			p.logger.WithError(err).Warn("Failed to release the schema migration lock")
+			// Discard the connection, its session still holds the lock
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
+
+// HealthCheck checks if the database is accessible
+func (p *PostgresDB) HealthCheck(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// Close closes the database connection
+func (p *PostgresDB) Close() error {
+	return p.db.Close()
+}
+
+// rebindDollar rewrites '?' placeholders to PostgreSQL's $1, $2, ...,
+// leaving quoted strings alone
+func rebindDollar(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n, inQuote := 0, false
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteRune(c)
+		case c == '?' && !inQuote:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
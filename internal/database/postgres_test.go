@@ -0,0 +1,64 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRebindDollar(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`SELECT 1`, `SELECT 1`},
+		{`DELETE FROM schema_migrations WHERE version = ?`, `DELETE FROM schema_migrations WHERE version = $1`},
+		{`INSERT INTO t (a, b, c) VALUES (?, ?, 'why?')`, `INSERT INTO t (a, b, c) VALUES ($1, $2, 'why?')`},
+	}
+
+	for _, tt := range tests {
+		if got := rebindDollar(tt.query); got != tt.want {
+			t.Errorf("rebindDollar(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestEventWhere_BBox(t *testing.T) {
+	filter := EventFilter{BBox: &models.BBox{MinLon: 0, MinLat: 1, MaxLon: 10, MaxLat: 11}}
+
+	// PostgreSQL matches a polygon that overlaps the box whatever its centroid
+	where, args := eventWhere(filter, postgisBBox)
+	if !strings.Contains(rebindDollar(where), `g.geom && ST_MakeEnvelope($1, $2, $3, $4, 4326)`) || strings.Contains(where, "g.longitude") {
+		t.Errorf("eventWhere(postgisBBox) = %q", where)
+	}
+	if fmt.Sprint(args) != "[0 1 10 11]" {
+		t.Errorf("eventWhere(postgisBBox) args = %v, want min_lon, min_lat, max_lon, max_lat", args)
+	}
+
+	where, args = eventWhere(filter, envelopeOverlapsBBox)
+	if !strings.Contains(where, `g.min_lon <= ? AND g.max_lon >= ? AND g.min_lat <= ? AND g.max_lat >= ?`) || fmt.Sprint(args) != "[10 0 11 1]" {
+		t.Errorf("eventWhere(envelopeOverlapsBBox) = %q, %v", where, args)
+	}
+}
+
+func TestGeometryGeoJSON(t *testing.T) {
+	point := geometryGeoJSON(models.EventGeometryRecord{Type: "Point", Coordinates: "[-120,38]"})
+	if point == nil || *point != `{"type":"Point","coordinates":[-120,38]}` {
+		t.Errorf("geometryGeoJSON(point) = %v", point)
+	}
+
+	if empty := geometryGeoJSON(models.EventGeometryRecord{Type: "Point", Coordinates: "null"}); empty != nil {
+		t.Errorf("geometryGeoJSON(no coordinates) = %q, want nil", *empty)
+	}
+}
+
+func TestOpen_UnsupportedDriver(t *testing.T) {
+	if _, err := Open(&config.DatabaseConfig{Driver: "oracle"}, logrus.New()); err == nil {
+		t.Error("Open() with an unsupported driver should fail")
+	}
+}
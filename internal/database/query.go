@@ -0,0 +1,475 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+// Event statuses accepted by EventFilter
+const (
+	EventStatusOpen   = "open"
+	EventStatusClosed = "closed"
+)
+
+// EventFilter selects the events returned by ListEvents. Zero fields do not
+// filter. From, To and BBox apply to the same observation: an event matches
+// if one of its geometries was observed within [From, To) and its bounding
+// box overlaps BBox, so a polygon that reaches into the box matches.
+type EventFilter struct {
+	CategoryID string
+	Status     string // EventStatusOpen, EventStatusClosed or empty for both
+	From       *time.Time
+	To         *time.Time
+	BBox       *models.BBox
+	AfterID    string // Only events with a greater id, for keyset paging
+
+	Limit  int // No limit if 0
+	Offset int // Only applies with a Limit
+}
+
+// filtersGeometries reports whether the filter has conditions on observations
+func (f EventFilter) filtersGeometries() bool {
+	return f.From != nil || f.To != nil || f.BBox != nil
+}
+
+// matchesEvent applies the event level conditions
+func (f EventFilter) matchesEvent(event *models.EventRecord) bool {
+	if f.AfterID != "" && event.ID <= f.AfterID {
+		return false
+	}
+
+	switch f.Status {
+	case EventStatusOpen:
+		if event.Closed != nil {
+			return false
+		}
+	case EventStatusClosed:
+		if event.Closed == nil {
+			return false
+		}
+	}
+
+	if f.CategoryID != "" {
+		found := false
+		for _, id := range event.CategoryIDs {
+			found = found || id == f.CategoryID
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !f.filtersGeometries() {
+		return true
+	}
+	for _, g := range event.Geometries {
+		if f.MatchesGeometry(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesGeometry reports whether an observation satisfies From, To and BBox
+func (f EventFilter) MatchesGeometry(g models.EventGeometryRecord) bool {
+	if f.From != nil && g.ObservedAt.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !g.ObservedAt.Before(*f.To) {
+		return false
+	}
+	if f.BBox != nil {
+		if box, ok := g.BBox(); ok {
+			return box.Overlaps(*f.BBox)
+		}
+		if g.Longitude == nil || g.Latitude == nil {
+			return false
+		}
+		lon, lat := *g.Longitude, *g.Latitude
+		if lon < f.BBox.MinLon || lon > f.BBox.MaxLon || lat < f.BBox.MinLat || lat > f.BBox.MaxLat {
+			return false
+		}
+	}
+	return true
+}
+
+// bboxCondition builds the condition on event_geometries g for a BBox filter
+type bboxCondition func(box models.BBox) (string, []any)
+
+// envelopeOverlapsBBox matches geometries whose stored bounding box overlaps box
+func envelopeOverlapsBBox(box models.BBox) (string, []any) {
+	return `g.min_lon <= ? AND g.max_lon >= ? AND g.min_lat <= ? AND g.max_lat >= ?`,
+		[]any{box.MaxLon, box.MinLon, box.MaxLat, box.MinLat}
+}
+
+// geometryEnvelope returns the min_lon, min_lat, max_lon and max_lat columns
+// of a geometry row, all nil if it has no coordinates
+func geometryEnvelope(g models.EventGeometryRecord) [4]*float64 {
+	box, ok := g.BBox()
+	if !ok {
+		return [4]*float64{}
+	}
+	return [4]*float64{&box.MinLon, &box.MinLat, &box.MaxLon, &box.MaxLat}
+}
+
+// eventColumns is the SELECT list scanned by scanEvent
+const eventColumns = `e.id, e.title, e.description, e.link, e.categories, e.sources, e.geometry, e.closed, e.created_at, e.updated_at`
+
+// eventQuery builds the SELECT for ListEvents with '?' placeholders. Events
+// are ordered by id so that pages are stable while the data changes.
+func eventQuery(filter EventFilter, bbox bboxCondition) (string, []any) {
+	where, args := eventWhere(filter, bbox)
+
+	query := `SELECT ` + eventColumns + ` FROM events e` + where + ` ORDER BY e.id`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d OFFSET %d`, filter.Limit, max(filter.Offset, 0))
+	}
+
+	return query, args
+}
+
+// eventWhere builds the WHERE clause over events e for filter, empty if
+// filter matches every event. Limit and Offset are ignored.
+func eventWhere(filter EventFilter, bbox bboxCondition) (string, []any) {
+	var where []string
+	var args []any
+
+	if filter.AfterID != "" {
+		where = append(where, `e.id > ?`)
+		args = append(args, filter.AfterID)
+	}
+
+	switch filter.Status {
+	case EventStatusOpen:
+		where = append(where, `e.closed IS NULL`)
+	case EventStatusClosed:
+		where = append(where, `e.closed IS NOT NULL`)
+	}
+
+	if filter.CategoryID != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM event_categories c WHERE c.event_id = e.id AND c.category_id = ?)`)
+		args = append(args, filter.CategoryID)
+	}
+
+	if filter.filtersGeometries() {
+		conditions := []string{`g.event_id = e.id`}
+		if filter.From != nil {
+			conditions = append(conditions, `g.observed_at >= ?`)
+			args = append(args, filter.From.UTC())
+		}
+		if filter.To != nil {
+			conditions = append(conditions, `g.observed_at < ?`)
+			args = append(args, filter.To.UTC())
+		}
+		if filter.BBox != nil {
+			condition, boxArgs := bbox(*filter.BBox)
+			conditions = append(conditions, condition)
+			args = append(args, boxArgs...)
+		}
+		where = append(where, `EXISTS (SELECT 1 FROM event_geometries g WHERE `+strings.Join(conditions, ` AND `)+`)`)
+	}
+
+	if len(where) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(where, ` AND `), args
+}
+
+// scanEvent scans a row selected with eventColumns
+func scanEvent(row rowScanner) (*models.EventRecord, error) {
+	var event models.EventRecord
+	var title, description, link, categories, sources, geometry, closed sql.NullString
+	var createdAt, updatedAt sql.NullTime
+
+	err := row.Scan(&event.ID, &title, &description, &link, &categories, &sources, &geometry, &closed, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Title = title.String
+	event.Description = description.String
+	event.Link = link.String
+	event.Categories = categories.String
+	event.Sources = sources.String
+	event.Geometry = geometry.String
+	if closed.Valid {
+		event.Closed = &closed.String
+	}
+	event.CreatedAt = createdAt.Time
+	event.UpdatedAt = updatedAt.Time
+
+	return &event, nil
+}
+
+// sqlReader serves the read queries shared by the SQL backends. rebind
+// rewrites the '?' placeholders for drivers that need another syntax, bbox
+// replaces the envelope columns for backends with spatial types.
+type sqlReader struct {
+	db     *sql.DB
+	rebind func(string) string
+	bbox   bboxCondition
+}
+
+func (r sqlReader) bind(query string) string {
+	if r.rebind == nil {
+		return query
+	}
+	return r.rebind(query)
+}
+
+func (r sqlReader) bboxCondition() bboxCondition {
+	if r.bbox == nil {
+		return envelopeOverlapsBBox
+	}
+	return r.bbox
+}
+
+// listEvents returns the events matching filter together with their child rows
+func (r sqlReader) listEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error) {
+	query, args := eventQuery(filter, r.bboxCondition())
+
+	rows, err := r.db.QueryContext(ctx, r.bind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.EventRecord
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	if err := r.loadEventChildren(ctx, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// countEvents counts the events matching filter
+func (r sqlReader) countEvents(ctx context.Context, filter EventFilter) (int64, error) {
+	where, args := eventWhere(filter, r.bboxCondition())
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, r.bind(`SELECT COUNT(*) FROM events e`+where), args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return count, nil
+}
+
+// getEvent returns an event with its child rows, or nil if it does not exist
+func (r sqlReader) getEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	query := `SELECT ` + eventColumns + ` FROM events e WHERE e.id = ?`
+
+	event, err := scanEvent(r.db.QueryRowContext(ctx, r.bind(query), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get event %s: %w", id, err)
+	}
+
+	if err := r.loadEventChildren(ctx, []*models.EventRecord{event}); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// loadEventChildren fills the normalized child rows of events
+func (r sqlReader) loadEventChildren(ctx context.Context, events []*models.EventRecord) error {
+	byID := make(map[string]*models.EventRecord, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	for start := 0; start < len(events); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(events))
+
+		ids := make([]any, 0, end-start)
+		for _, event := range events[start:end] {
+			ids = append(ids, event.ID)
+		}
+		in := placeholders(len(ids))
+
+		if err := r.loadGeometries(ctx, in, ids, byID); err != nil {
+			return err
+		}
+
+		err := r.eachRow(ctx, fmt.Sprintf(`SELECT event_id, source_id, url FROM event_sources WHERE event_id IN (%s) ORDER BY event_id, source_id`, in), ids,
+			func(rows *sql.Rows) error {
+				var source models.EventSourceRecord
+				var url sql.NullString
+				if err := rows.Scan(&source.EventID, &source.SourceID, &url); err != nil {
+					return err
+				}
+				source.URL = url.String
+				event := byID[source.EventID]
+				event.SourceLinks = append(event.SourceLinks, source)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("failed to load event sources: %w", err)
+		}
+
+		err = r.eachRow(ctx, fmt.Sprintf(`SELECT event_id, category_id FROM event_categories WHERE event_id IN (%s) ORDER BY event_id, category_id`, in), ids,
+			func(rows *sql.Rows) error {
+				var eventID, categoryID string
+				if err := rows.Scan(&eventID, &categoryID); err != nil {
+					return err
+				}
+				event := byID[eventID]
+				event.CategoryIDs = append(event.CategoryIDs, categoryID)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("failed to load event categories: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadGeometries fills the geometries of the events whose ids are bound to in
+func (r sqlReader) loadGeometries(ctx context.Context, in string, ids []any, byID map[string]*models.EventRecord) error {
+	query := fmt.Sprintf(`
+		SELECT event_id, seq, observed_at, geometry_type, longitude, latitude,
+			magnitude_value, magnitude_unit, magnitude_description, coordinates
+		FROM event_geometries
+		WHERE event_id IN (%s)
+		ORDER BY event_id, seq
+	`, in)
+
+	err := r.eachRow(ctx, query, ids, func(rows *sql.Rows) error {
+		var g models.EventGeometryRecord
+		var observedAt sql.NullTime
+		var longitude, latitude, magnitudeValue sql.NullFloat64
+		var magnitudeUnit, magnitudeDescription, coordinates sql.NullString
+		if err := rows.Scan(&g.EventID, &g.Seq, &observedAt, &g.Type, &longitude, &latitude,
+			&magnitudeValue, &magnitudeUnit, &magnitudeDescription, &coordinates); err != nil {
+			return err
+		}
+
+		g.ObservedAt = observedAt.Time.UTC()
+		g.Longitude = nullFloat(longitude)
+		g.Latitude = nullFloat(latitude)
+		g.MagnitudeValue = nullFloat(magnitudeValue)
+		g.MagnitudeUnit = nullString(magnitudeUnit)
+		g.MagnitudeDescription = nullString(magnitudeDescription)
+		g.Coordinates = coordinates.String
+
+		event := byID[g.EventID]
+		event.Geometries = append(event.Geometries, g)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load event geometries: %w", err)
+	}
+	return nil
+}
+
+// eachRow runs query and calls fn for every row
+func (r sqlReader) eachRow(ctx context.Context, query string, args []any, fn func(rows *sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, r.bind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// listCategories returns all categories ordered by id
+func (r sqlReader) listCategories(ctx context.Context) ([]*models.CategoryRecord, error) {
+	query := `SELECT id, title, link, description, layers, created_at, updated_at FROM categories ORDER BY id`
+
+	var categories []*models.CategoryRecord
+	err := r.eachRow(ctx, query, nil, func(rows *sql.Rows) error {
+		var category models.CategoryRecord
+		var title, link, description, layers sql.NullString
+		var createdAt, updatedAt sql.NullTime
+		if err := rows.Scan(&category.ID, &title, &link, &description, &layers, &createdAt, &updatedAt); err != nil {
+			return err
+		}
+		category.Title = title.String
+		category.Link = link.String
+		category.Description = description.String
+		category.Layers = layers.String
+		category.CreatedAt = createdAt.Time
+		category.UpdatedAt = updatedAt.Time
+		categories = append(categories, &category)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// listETLRuns returns runs, most recently started first
+func (r sqlReader) listETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error) {
+	query := `SELECT ` + etlRunColumns + ` FROM etl_runs ORDER BY started_at DESC, id DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d OFFSET %d`, limit, max(offset, 0))
+	}
+
+	var runs []*ETLRunInfo
+	err := r.eachRow(ctx, query, nil, func(rows *sql.Rows) error {
+		run, err := scanETLRun(rows)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ETL runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// getETLRun returns a run, or nil if it does not exist
+func (r sqlReader) getETLRun(ctx context.Context, id int64) (*ETLRunInfo, error) {
+	query := `SELECT ` + etlRunColumns + ` FROM etl_runs WHERE id = ?`
+
+	run, err := scanETLRun(r.db.QueryRowContext(ctx, r.bind(query), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ETL run %d: %w", id, err)
+	}
+
+	return run, nil
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
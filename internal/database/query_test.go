@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+func TestListEvents_BBoxOverlap(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"sqlite": func(t *testing.T) Store { return newTestSQLiteDB(t) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := context.Background()
+
+			// The polygon crosses the east edge of the box, its centroid lies outside
+			lon, lat := 15.0, 5.0
+			_, err := store.UpsertEvents(ctx, []*models.EventRecord{{
+				ID: "EONET_1",
+				Geometries: []models.EventGeometryRecord{{
+					EventID: "EONET_1", Type: "Polygon", Longitude: &lon, Latitude: &lat,
+					Coordinates: "[[[5,0],[25,0],[25,10],[5,10],[5,0]]]",
+				}},
+			}})
+			if err != nil {
+				t.Fatalf("UpsertEvents() error = %v", err)
+			}
+
+			tests := []struct {
+				box  models.BBox
+				want int
+			}{
+				{models.BBox{MinLon: 0, MinLat: 0, MaxLon: 10, MaxLat: 10}, 1},
+				{models.BBox{MinLon: 30, MinLat: 0, MaxLon: 40, MaxLat: 10}, 0},
+			}
+			for _, tt := range tests {
+				filter := EventFilter{BBox: &tt.box}
+				if events, err := store.ListEvents(ctx, filter); err != nil || len(events) != tt.want {
+					t.Errorf("ListEvents(%+v) = %d events, %v, want %d", tt.box, len(events), err, tt.want)
+				}
+				if count, err := store.CountEvents(ctx, filter); err != nil || count != int64(tt.want) {
+					t.Errorf("CountEvents(%+v) = %d, %v, want %d", tt.box, count, err, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,564 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	_ Store      = (*SQLiteDB)(nil)
+	_ Migratable = (*SQLiteDB)(nil)
+)
+
+// SQLiteDB stores everything in a single SQLite file laid out as a
+// GeoPackage, for local and offline use
+type SQLiteDB struct {
+	db     *sql.DB
+	config *config.DatabaseConfig
+	logger *logrus.Logger
+}
+
+// NewSQLiteDB opens or creates the GeoPackage file at cfg.Path
+func NewSQLiteDB(cfg *config.DatabaseConfig, logger *logrus.Logger) (*SQLiteDB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_time_format", "sqlite")
+	// Take the write lock when a transaction begins, so that a transaction
+	// which reads before it writes, like a migration, is not interleaved
+	// with another process
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database file: %w", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database file %s: %w", cfg.Path, err)
+	}
+
+	// SQLite has a single writer, serialize access instead of retrying on SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	return &SQLiteDB{
+		db:     db,
+		config: cfg,
+		logger: logger,
+	}, nil
+}
+
+// UpsertEvents inserts new events and updates changed ones with
+// INSERT ... ON CONFLICT, replacing their child rows
+func (s *SQLiteDB) UpsertEvents(ctx context.Context, events []*models.EventRecord) (UpsertResult, error) {
+	if len(events) == 0 {
+		return UpsertResult{}, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.WithError(err).Error("Failed to rollback transaction")
+		}
+	}()
+
+	existing, err := loadExistingEvents(ctx, tx, events)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	withChildren, err := loadEventsWithChildren(ctx, tx, events)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	inserts, updates, result := classifyEvents(existing, withChildren, events)
+
+	query := `
+		INSERT INTO events (id, title, description, link, categories, sources, geometry, closed, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			link = excluded.link,
+			categories = excluded.categories,
+			sources = excluded.sources,
+			geometry = excluded.geometry,
+			closed = excluded.closed,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, event := range append(inserts, updates...) {
+		_, err := stmt.ExecContext(ctx,
+			event.ID,
+			event.Title,
+			event.Description,
+			event.Link,
+			event.Categories,
+			event.Sources,
+			event.Geometry,
+			event.Closed,
+		)
+		if err != nil {
+			return UpsertResult{}, fmt.Errorf("failed to upsert event %s: %w", event.ID, err)
+		}
+
+		if err := s.replaceEventChildren(ctx, tx, event); err != nil {
+			return UpsertResult{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+	}).Info("Successfully upserted events")
+	return result, nil
+}
+
+// replaceEventChildren deletes the child rows of an event and inserts the current ones
+func (s *SQLiteDB) replaceEventChildren(ctx context.Context, tx *sql.Tx, event *models.EventRecord) error {
+	for _, table := range []string{"event_geometries", "event_sources", "event_categories"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE event_id = ?`, table), event.ID); err != nil {
+			return fmt.Errorf("failed to delete child rows of event %s: %w", event.ID, err)
+		}
+	}
+
+	for _, g := range event.Geometries {
+		geom, err := encodeGeoPackageGeometry(g.Type, g.Coordinates)
+		if err != nil {
+			return fmt.Errorf("failed to encode geometry %d of event %s: %w", g.Seq, event.ID, err)
+		}
+
+		envelope := geometryEnvelope(g)
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO event_geometries (event_id, seq, observed_at, geometry_type, longitude, latitude,
+				magnitude_value, magnitude_unit, magnitude_description, coordinates, geom,
+				min_lon, min_lat, max_lon, max_lat)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			event.ID,
+			g.Seq,
+			g.ObservedAt.UTC(),
+			g.Type,
+			g.Longitude,
+			g.Latitude,
+			g.MagnitudeValue,
+			g.MagnitudeUnit,
+			g.MagnitudeDescription,
+			g.Coordinates,
+			geom,
+			envelope[0], envelope[1], envelope[2], envelope[3],
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert geometry %d of event %s: %w", g.Seq, event.ID, err)
+		}
+	}
+
+	for _, source := range event.SourceLinks {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO event_sources (event_id, source_id, url) VALUES (?, ?, ?)`,
+			event.ID, source.SourceID, source.URL); err != nil {
+			return fmt.Errorf("failed to insert source %s of event %s: %w", source.SourceID, event.ID, err)
+		}
+	}
+
+	for _, categoryID := range event.CategoryIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO event_categories (event_id, category_id) VALUES (?, ?)`,
+			event.ID, categoryID); err != nil {
+			return fmt.Errorf("failed to insert category %s of event %s: %w", categoryID, event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// UpsertCategories inserts new categories and updates changed ones
+func (s *SQLiteDB) UpsertCategories(ctx context.Context, categories []*models.CategoryRecord) (UpsertResult, error) {
+	if len(categories) == 0 {
+		return UpsertResult{}, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.WithError(err).Error("Failed to rollback transaction")
+		}
+	}()
+
+	existing, err := loadExistingCategories(ctx, tx, categories)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	inserts, updates, result := classifyCategories(existing, categories)
+
+	query := `
+		INSERT INTO categories (id, title, link, description, layers, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET
+			title = excluded.title,
+			link = excluded.link,
+			description = excluded.description,
+			layers = excluded.layers,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, category := range append(inserts, updates...) {
+		_, err := stmt.ExecContext(ctx,
+			category.ID,
+			category.Title,
+			category.Link,
+			category.Description,
+			category.Layers,
+		)
+		if err != nil {
+			return UpsertResult{}, fmt.Errorf("failed to upsert category %s: %w", category.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+	}).Info("Successfully upserted categories")
+	return result, nil
+}
+
+// ListEvents returns the events matching filter ordered by id
+func (s *SQLiteDB) ListEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error) {
+	return sqlReader{db: s.db}.listEvents(ctx, filter)
+}
+
+// CountEvents counts the events matching filter
+func (s *SQLiteDB) CountEvents(ctx context.Context, filter EventFilter) (int64, error) {
+	return sqlReader{db: s.db}.countEvents(ctx, filter)
+}
+
+// GetEvent returns an event with its child rows, or nil if it does not exist
+func (s *SQLiteDB) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	return sqlReader{db: s.db}.getEvent(ctx, id)
+}
+
+// ListCategories returns all categories ordered by id
+func (s *SQLiteDB) ListCategories(ctx context.Context) ([]*models.CategoryRecord, error) {
+	return sqlReader{db: s.db}.listCategories(ctx)
+}
+
+// StartETLRun records the start of an ETL run, SQLite assigns the id
+func (s *SQLiteDB) StartETLRun(ctx context.Context, run ETLRunStart) (int64, error) {
+	query := `
+		INSERT INTO etl_runs (started_at, status, heartbeat_at, app_version, git_commit, config_hash)
+		VALUES (?, 'running', ?, ?, ?, ?)
+	`
+
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, query, now, now, run.AppVersion, run.GitCommit, run.ConfigHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start ETL run: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ETL run id: %w", err)
+	}
+
+	return id, nil
+}
+
+// CompleteETLRun records the completion of an ETL run
+func (s *SQLiteDB) CompleteETLRun(ctx context.Context, runID int64, result ETLRunResult) error {
+	query := `
+		UPDATE etl_runs
+		SET completed_at = ?,
+			status = ?,
+			events_processed = ?,
+			categories_processed = ?,
+			error_message = ?,
+			extract_duration_ms = ?,
+			transform_duration_ms = ?,
+			load_duration_ms = ?,
+			api_requests = ?,
+			bytes_downloaded = ?
+		WHERE id = ?
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		time.Now().UTC(),
+		result.Status,
+		result.EventsProcessed,
+		result.CategoriesProcessed,
+		result.ErrorMessage,
+		result.ExtractDurationMs,
+		result.TransformDurationMs,
+		result.LoadDurationMs,
+		result.APIRequests,
+		result.BytesDownloaded,
+		runID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete ETL run: %w", err)
+	}
+
+	return nil
+}
+
+// HeartbeatETLRun records that a running run is still alive
+func (s *SQLiteDB) HeartbeatETLRun(ctx context.Context, runID int64) error {
+	query := `UPDATE etl_runs SET heartbeat_at = ? WHERE id = ? AND status = 'running'`
+	if _, err := s.db.ExecContext(ctx, query, time.Now().UTC(), runID); err != nil {
+		return fmt.Errorf("failed to record ETL run heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// AbandonStaleETLRuns marks running runs without a heartbeat within timeout as abandoned
+func (s *SQLiteDB) AbandonStaleETLRuns(ctx context.Context, timeout time.Duration) (int64, error) {
+	query := `
+		UPDATE etl_runs
+		SET status = 'abandoned',
+			completed_at = ?,
+			error_message = ?
+		WHERE status = 'running'
+			AND COALESCE(heartbeat_at, started_at) < ?
+	`
+
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, query, now, abandonedMessage(timeout), now.Add(-timeout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to abandon stale ETL runs: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// AcquireLease takes or renews a lease with a conditional upsert
+func (s *SQLiteDB) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO etl_locks (name, owner, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			acquired_at = CASE WHEN etl_locks.owner = excluded.owner THEN etl_locks.acquired_at ELSE excluded.acquired_at END,
+			owner = excluded.owner,
+			renewed_at = excluded.renewed_at,
+			expires_at = excluded.expires_at
+		WHERE etl_locks.owner = excluded.owner OR etl_locks.expires_at < excluded.renewed_at
+	`
+
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, query, name, owner, now, now, now.Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease expires the lease if owner holds it
+func (s *SQLiteDB) ReleaseLease(ctx context.Context, name, owner string) error {
+	query := `UPDATE etl_locks SET expires_at = ? WHERE name = ? AND owner = ?`
+	if _, err := s.db.ExecContext(ctx, query, time.Now().UTC(), name, owner); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// GetLastETLRun returns information about the last ETL run
+func (s *SQLiteDB) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
+	query := `SELECT ` + etlRunColumns + ` FROM etl_runs ORDER BY started_at DESC, id DESC LIMIT 1`
+
+	run, err := scanETLRun(s.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No previous runs
+		}
+		return nil, fmt.Errorf("failed to get last ETL run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ListETLRuns returns runs, most recently started first
+func (s *SQLiteDB) ListETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error) {
+	return sqlReader{db: s.db}.listETLRuns(ctx, limit, offset)
+}
+
+// GetETLRun returns a run, or nil if it does not exist
+func (s *SQLiteDB) GetETLRun(ctx context.Context, id int64) (*ETLRunInfo, error) {
+	return sqlReader{db: s.db}.getETLRun(ctx, id)
+}
+
+// GetWatermark returns the high-water mark of a source, or nil if none was recorded
+func (s *SQLiteDB) GetWatermark(ctx context.Context, source string) (*time.Time, error) {
+	var watermark time.Time
+	err := s.db.QueryRowContext(ctx, `SELECT watermark FROM etl_watermarks WHERE source = ?`, source).Scan(&watermark)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get watermark: %w", err)
+	}
+
+	return &watermark, nil
+}
+
+// SetWatermark records the high-water mark of a source
+func (s *SQLiteDB) SetWatermark(ctx context.Context, source string, watermark time.Time) error {
+	query := `
+		INSERT INTO etl_watermarks (source, watermark, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (source) DO UPDATE SET watermark = excluded.watermark, updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, source, watermark.UTC()); err != nil {
+		return fmt.Errorf("failed to set watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ResetWatermark removes the high-water mark of a source
+func (s *SQLiteDB) ResetWatermark(ctx context.Context, source string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM etl_watermarks WHERE source = ?`, source); err != nil {
+		return fmt.Errorf("failed to reset watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ListBackfillChunks returns the recorded chunks that lie within [from, to)
+func (s *SQLiteDB) ListBackfillChunks(ctx context.Context, from, to time.Time) ([]BackfillChunk, error) {
+	query := `
+		SELECT chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at
+		FROM etl_backfill_chunks
+		WHERE chunk_start >= ? AND chunk_end <= ?
+		ORDER BY chunk_start
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []BackfillChunk
+	for rows.Next() {
+		var chunk BackfillChunk
+		var errorMsg sql.NullString
+		var completedAt sql.NullTime
+		if err := rows.Scan(&chunk.Start, &chunk.End, &chunk.Status, &chunk.EventsLoaded, &errorMsg, &chunk.StartedAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill chunk: %w", err)
+		}
+		if errorMsg.Valid {
+			chunk.ErrorMessage = &errorMsg.String
+		}
+		if completedAt.Valid {
+			chunk.CompletedAt = &completedAt.Time
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, rows.Err()
+}
+
+// SaveBackfillChunk records the progress of a backfill chunk
+func (s *SQLiteDB) SaveBackfillChunk(ctx context.Context, chunk *BackfillChunk) error {
+	query := `
+		INSERT INTO etl_backfill_chunks (chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chunk_start, chunk_end) DO UPDATE SET
+			status = excluded.status,
+			events_loaded = excluded.events_loaded,
+			error_message = excluded.error_message,
+			completed_at = excluded.completed_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		chunk.Start.UTC(),
+		chunk.End.UTC(),
+		chunk.Status,
+		chunk.EventsLoaded,
+		chunk.ErrorMessage,
+		time.Now().UTC(),
+		chunk.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save backfill chunk: %w", err)
+	}
+
+	return nil
+}
+
+// InitializeDatabase brings the database schema up to date based on mode
+func (s *SQLiteDB) InitializeDatabase(ctx context.Context, mode InitMode) error {
+	migrator, err := s.Migrator()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return initializeWithMigrator(ctx, migrator, mode)
+}
+
+// Migrator returns a migrator for the SQLite schema migrations
+func (s *SQLiteDB) Migrator() (*Migrator, error) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations/sqlite")
+	if err != nil {
+		return nil, err
+	}
+	migrator := NewMigrator(s.db, migrations, s.logger)
+	// The immediate transaction of each migration holds the database lock
+	migrator.transactional = true
+	return migrator, nil
+}
+
+// HealthCheck checks if the database file is accessible
+func (s *SQLiteDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database file
+func (s *SQLiteDB) Close() error {
+	return s.db.Close()
+}
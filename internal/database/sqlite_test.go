@@ -0,0 +1,306 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestSQLiteDB(t *testing.T) *SQLiteDB {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	store, err := Open(&config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "test.gpkg"),
+	}, logger)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.InitializeDatabase(context.Background(), InitModeCreate); err != nil {
+		t.Fatalf("InitializeDatabase() error = %v", err)
+	}
+
+	return store.(*SQLiteDB)
+}
+
+func TestSQLiteDB_GeoPackageHeader(t *testing.T) {
+	db := newTestSQLiteDB(t)
+
+	var applicationID, userVersion int
+	if err := db.db.QueryRow(`PRAGMA application_id`).Scan(&applicationID); err != nil {
+		t.Fatalf("failed to read application_id: %v", err)
+	}
+	if err := db.db.QueryRow(`PRAGMA user_version`).Scan(&userVersion); err != nil {
+		t.Fatalf("failed to read user_version: %v", err)
+	}
+
+	// "GPKG" as a big-endian integer, GeoPackage 1.3
+	if applicationID != 0x47504B47 || userVersion != 10300 {
+		t.Errorf("application_id = %#x, user_version = %d", applicationID, userVersion)
+	}
+
+	var geometryColumn string
+	err := db.db.QueryRow(`SELECT column_name FROM gpkg_geometry_columns WHERE table_name = 'event_geometries'`).Scan(&geometryColumn)
+	if err != nil || geometryColumn != "geom" {
+		t.Errorf("event_geometries geometry column = %q, %v", geometryColumn, err)
+	}
+}
+
+func TestSQLiteDB_UpsertEvents(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	ctx := context.Background()
+
+	lon, lat := -120.0, 38.0
+	event := &models.EventRecord{
+		ID:          "EONET_1",
+		Title:       "Wildfire",
+		Categories:  `["wildfires"]`,
+		CategoryIDs: []string{"wildfires"},
+		SourceLinks: []models.EventSourceRecord{{EventID: "EONET_1", SourceID: "InciWeb", URL: "https://example.com"}},
+		Geometries: []models.EventGeometryRecord{
+			{EventID: "EONET_1", Seq: 0, ObservedAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Type: "Point", Longitude: &lon, Latitude: &lat, Coordinates: "[-120,38]"},
+			{EventID: "EONET_1", Seq: 1, ObservedAt: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), Type: "Polygon", Coordinates: "[[[-121,37],[-119,37],[-119,39],[-121,37]]]"},
+		},
+	}
+
+	if _, err := db.UpsertCategories(ctx, []*models.CategoryRecord{{ID: "wildfires", Title: "Wildfires"}}); err != nil {
+		t.Fatalf("UpsertCategories() error = %v", err)
+	}
+
+	result, err := db.UpsertEvents(ctx, []*models.EventRecord{event})
+	if err != nil {
+		t.Fatalf("UpsertEvents() error = %v", err)
+	}
+	if result.Inserted != 1 {
+		t.Errorf("first UpsertEvents() = %+v, want 1 inserted", result)
+	}
+
+	result, err = db.UpsertEvents(ctx, []*models.EventRecord{event})
+	if err != nil {
+		t.Fatalf("UpsertEvents() error = %v", err)
+	}
+	if result.Unchanged != 1 {
+		t.Errorf("second UpsertEvents() = %+v, want 1 unchanged", result)
+	}
+
+	event.Title = "Wildfire (renamed)"
+	if result, err = db.UpsertEvents(ctx, []*models.EventRecord{event}); err != nil || result.Updated != 1 {
+		t.Errorf("changed UpsertEvents() = %+v, %v, want 1 updated", result, err)
+	}
+
+	stored, err := db.GetEvent(ctx, "EONET_1")
+	if err != nil || stored == nil || len(stored.Geometries) != 2 || len(stored.SourceLinks) != 1 || len(stored.CategoryIDs) != 1 {
+		t.Fatalf("GetEvent() = %+v, %v", stored, err)
+	}
+	if !stored.Geometries[0].ObservedAt.Equal(event.Geometries[0].ObservedAt) || *stored.Geometries[0].Longitude != lon {
+		t.Errorf("GetEvent() geometry = %+v", stored.Geometries[0])
+	}
+
+	from := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+	filters := []struct {
+		filter EventFilter
+		want   int
+	}{
+		{EventFilter{}, 1},
+		{EventFilter{CategoryID: "wildfires", Status: EventStatusOpen}, 1},
+		{EventFilter{Status: EventStatusClosed}, 0},
+		// The polygon observed from From reaches into the box
+		{EventFilter{From: &from, BBox: &models.BBox{MinLon: -119.5, MinLat: 38.5, MaxLon: -119, MaxLat: 39}}, 1},
+		// Only the polygon reaches into the box but it was observed after To
+		{EventFilter{To: &from, BBox: &models.BBox{MinLon: -119.5, MinLat: 38.5, MaxLon: -119, MaxLat: 39}}, 0},
+		{EventFilter{Limit: 1, Offset: 1}, 0},
+	}
+	for _, tt := range filters {
+		if events, err := db.ListEvents(ctx, tt.filter); err != nil || len(events) != tt.want {
+			t.Errorf("ListEvents(%+v) = %d events, %v, want %d", tt.filter, len(events), err, tt.want)
+		}
+		if tt.filter.Limit > 0 {
+			continue
+		}
+		if count, err := db.CountEvents(ctx, tt.filter); err != nil || count != int64(tt.want) {
+			t.Errorf("CountEvents(%+v) = %d, %v, want %d", tt.filter, count, err, tt.want)
+		}
+	}
+
+	if categories, err := db.ListCategories(ctx); err != nil || len(categories) != 1 {
+		t.Errorf("ListCategories() = %v, %v", categories, err)
+	}
+
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM event_geometries WHERE event_id = 'EONET_1' AND geom IS NOT NULL`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("event_geometries rows with geom = %d, %v, want 2", count, err)
+	}
+
+	var blob []byte
+	if err := db.db.QueryRow(`SELECT geom FROM event_geometries WHERE event_id = 'EONET_1' AND seq = 0`).Scan(&blob); err != nil {
+		t.Fatalf("failed to read geometry: %v", err)
+	}
+	if !bytes.HasPrefix(blob, []byte("GP")) || binary.LittleEndian.Uint32(blob[4:8]) != gpkgSRSWGS84 {
+		t.Errorf("geometry blob header = %x", blob[:8])
+	}
+}
+
+func TestSQLiteDB_Runs(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	ctx := context.Background()
+
+	if run, err := db.GetLastETLRun(ctx); err != nil || run != nil {
+		t.Fatalf("GetLastETLRun() on an empty database = %v, %v", run, err)
+	}
+
+	runID, err := db.StartETLRun(ctx, ETLRunStart{AppVersion: "1.2.3", GitCommit: "abc123", ConfigHash: "hash"})
+	if err != nil {
+		t.Fatalf("StartETLRun() error = %v", err)
+	}
+
+	// Runs started within the same second get distinct ids
+	other, err := db.StartETLRun(ctx, ETLRunStart{})
+	if err != nil || other == runID {
+		t.Fatalf("second StartETLRun() = %d, %v, want an id other than %d", other, err, runID)
+	}
+
+	result := ETLRunResult{
+		Status:              "completed",
+		EventsProcessed:     3,
+		CategoriesProcessed: 2,
+		ExtractDurationMs:   150,
+		LoadDurationMs:      20,
+		APIRequests:         2,
+		BytesDownloaded:     4096,
+	}
+	if err := db.CompleteETLRun(ctx, runID, result); err != nil {
+		t.Fatalf("CompleteETLRun() error = %v", err)
+	}
+
+	run, err := db.GetETLRun(ctx, runID)
+	if err != nil || run == nil {
+		t.Fatalf("GetETLRun() = %v, %v", run, err)
+	}
+	if run.Status != "completed" || run.EventsProcessed != 3 || run.CategoriesProcessed != 2 || run.CompletedAt == nil {
+		t.Errorf("run = %+v", run)
+	}
+	if run.ExtractDurationMs != 150 || run.APIRequests != 2 || run.BytesDownloaded != 4096 {
+		t.Errorf("run stats = %+v", run)
+	}
+	if run.AppVersion != "1.2.3" || run.GitCommit != "abc123" || run.ConfigHash != "hash" {
+		t.Errorf("run build info = %+v", run)
+	}
+
+	if last, err := db.GetLastETLRun(ctx); err != nil || last == nil {
+		t.Errorf("GetLastETLRun() = %v, %v", last, err)
+	}
+	if missing, err := db.GetETLRun(ctx, other+1); err != nil || missing != nil {
+		t.Errorf("GetETLRun() of a missing run = %v, %v, want nil", missing, err)
+	}
+
+	runs, err := db.ListETLRuns(ctx, 10, 0)
+	if err != nil || len(runs) != 2 || runs[0].ID != other || runs[1].ID != runID {
+		t.Errorf("ListETLRuns() = %v, %v, want runs %d and %d", runs, err, other, runID)
+	}
+	if runs, err := db.ListETLRuns(ctx, 1, 1); err != nil || len(runs) != 1 || runs[0].ID != runID {
+		t.Errorf("ListETLRuns(1, 1) = %v, %v, want run %d", runs, err, runID)
+	}
+
+	watermark := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.SetWatermark(ctx, "eonet", watermark); err != nil {
+		t.Fatalf("SetWatermark() error = %v", err)
+	}
+	if got, err := db.GetWatermark(ctx, "eonet"); err != nil || got == nil || !got.Equal(watermark) {
+		t.Errorf("GetWatermark() = %v, %v, want %v", got, err, watermark)
+	}
+}
+
+func TestEncodeGeoPackageGeometry(t *testing.T) {
+	point, err := encodeGeoPackageGeometry("Point", "[-120,38]")
+	if err != nil {
+		t.Fatalf("encodeGeoPackageGeometry(point) error = %v", err)
+	}
+	// 8 byte header without envelope, then byte order, type and two doubles
+	if len(point) != 8+1+4+16 || point[3] != 0x01 || binary.LittleEndian.Uint32(point[9:13]) != wkbPoint {
+		t.Errorf("encodeGeoPackageGeometry(point) = %x", point)
+	}
+
+	polygon, err := encodeGeoPackageGeometry("Polygon", "[[[-121,37],[-119,37],[-119,39],[-121,37]]]")
+	if err != nil {
+		t.Fatalf("encodeGeoPackageGeometry(polygon) error = %v", err)
+	}
+	// 8 byte header, 32 byte envelope, then the WKB polygon
+	if polygon[3] != 0x03 || binary.LittleEndian.Uint32(polygon[41:45]) != wkbPolygon {
+		t.Errorf("encodeGeoPackageGeometry(polygon) = %x", polygon)
+	}
+
+	if empty, err := encodeGeoPackageGeometry("Point", "null"); err != nil || empty != nil {
+		t.Errorf("encodeGeoPackageGeometry(no coordinates) = %x, %v", empty, err)
+	}
+}
+
+func TestSQLiteDB_AbandonStaleRuns(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	ctx := context.Background()
+
+	runID, err := db.StartETLRun(ctx, ETLRunStart{})
+	if err != nil {
+		t.Fatalf("StartETLRun() error = %v", err)
+	}
+	if err := db.HeartbeatETLRun(ctx, runID); err != nil {
+		t.Fatalf("HeartbeatETLRun() error = %v", err)
+	}
+
+	if n, err := db.AbandonStaleETLRuns(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("AbandonStaleETLRuns(1h) = %d, %v, want 0", n, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n, err := db.AbandonStaleETLRuns(ctx, time.Millisecond); err != nil || n != 1 {
+		t.Fatalf("AbandonStaleETLRuns(1ms) = %d, %v, want 1", n, err)
+	}
+
+	run, err := db.GetLastETLRun(ctx)
+	if err != nil || run.Status != "abandoned" || run.HeartbeatAt == nil || run.ErrorMessage == nil {
+		t.Errorf("GetLastETLRun() = %+v, %v, want an abandoned run", run, err)
+	}
+}
+
+func TestSQLiteDB_Lease(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	ctx := context.Background()
+
+	if ok, err := db.AcquireLease(ctx, "etl", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("AcquireLease(a) = %v, %v, want acquired", ok, err)
+	}
+	if ok, err := db.AcquireLease(ctx, "etl", "b", time.Minute); err != nil || ok {
+		t.Fatalf("AcquireLease(b) while a holds it = %v, %v, want refused", ok, err)
+	}
+	if ok, err := db.AcquireLease(ctx, "etl", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("renewing AcquireLease(a) = %v, %v, want acquired", ok, err)
+	}
+
+	// Only the holder can release
+	if err := db.ReleaseLease(ctx, "etl", "b"); err != nil {
+		t.Fatalf("ReleaseLease(b) error = %v", err)
+	}
+	if ok, _ := db.AcquireLease(ctx, "etl", "b", time.Minute); ok {
+		t.Fatal("AcquireLease(b) after a foreign release = true, want false")
+	}
+
+	if err := db.ReleaseLease(ctx, "etl", "a"); err != nil {
+		t.Fatalf("ReleaseLease(a) error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if ok, err := db.AcquireLease(ctx, "etl", "b", time.Minute); err != nil || !ok {
+		t.Errorf("AcquireLease(b) after release = %v, %v, want acquired", ok, err)
+	}
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventStore persists EONET events and categories
+type EventStore interface {
+	// UpsertEvents inserts new events and updates changed ones, together with
+	// their child rows
+	UpsertEvents(ctx context.Context, events []*models.EventRecord) (UpsertResult, error)
+	// UpsertCategories inserts new categories and updates changed ones
+	UpsertCategories(ctx context.Context, categories []*models.CategoryRecord) (UpsertResult, error)
+}
+
+// RunStore tracks ETL runs and extraction progress
+type RunStore interface {
+	// StartETLRun records a new run and returns its unique id
+	StartETLRun(ctx context.Context, run ETLRunStart) (int64, error)
+	CompleteETLRun(ctx context.Context, runID int64, result ETLRunResult) error
+	// HeartbeatETLRun records that a running run is still alive
+	HeartbeatETLRun(ctx context.Context, runID int64) error
+	// AbandonStaleETLRuns marks running runs without a heartbeat within
+	// timeout as abandoned and returns how many it marked
+	AbandonStaleETLRuns(ctx context.Context, timeout time.Duration) (int64, error)
+	// GetLastETLRun returns nil if no run was recorded yet
+	GetLastETLRun(ctx context.Context) (*ETLRunInfo, error)
+	// ListETLRuns returns runs, most recently started first. No limit if
+	// limit is 0.
+	ListETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error)
+	// GetETLRun returns nil if no run has the id
+	GetETLRun(ctx context.Context, id int64) (*ETLRunInfo, error)
+
+	// GetWatermark returns nil if no watermark was recorded for source
+	GetWatermark(ctx context.Context, source string) (*time.Time, error)
+	SetWatermark(ctx context.Context, source string, watermark time.Time) error
+	ResetWatermark(ctx context.Context, source string) error
+
+	// ListBackfillChunks returns the recorded chunks that lie within [from, to)
+	ListBackfillChunks(ctx context.Context, from, to time.Time) ([]BackfillChunk, error)
+	SaveBackfillChunk(ctx context.Context, chunk *BackfillChunk) error
+}
+
+// QueryStore reads the loaded events and categories
+type QueryStore interface {
+	// ListEvents returns the events matching filter ordered by id, with
+	// their child rows
+	ListEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error)
+	// CountEvents counts the events matching filter, ignoring Limit and Offset
+	CountEvents(ctx context.Context, filter EventFilter) (int64, error)
+	// GetEvent returns nil if no event has the id
+	GetEvent(ctx context.Context, id string) (*models.EventRecord, error)
+	// ListCategories returns all categories ordered by id
+	ListCategories(ctx context.Context) ([]*models.CategoryRecord, error)
+}
+
+// LeaseStore grants named, time-limited leases that at most one owner holds
+// at a time
+type LeaseStore interface {
+	// AcquireLease takes the lease for owner, or renews it if owner already
+	// holds it, until ttl from now. It reports false if another owner holds
+	// a lease that has not expired.
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease expires the lease if owner holds it
+	ReleaseLease(ctx context.Context, name, owner string) error
+}
+
+// ETLRunStart identifies the binary and configuration of a run
+type ETLRunStart struct {
+	AppVersion string
+	GitCommit  string
+	ConfigHash string
+}
+
+// ETLRunResult is the outcome of a run
+type ETLRunResult struct {
+	Status              string
+	EventsProcessed     int
+	CategoriesProcessed int
+	ErrorMessage        *string
+
+	ExtractDurationMs   int64
+	TransformDurationMs int64
+	LoadDurationMs      int64
+	APIRequests         int64
+	BytesDownloaded     int64
+}
+
+// Store is the storage backend of the ETL pipeline
+type Store interface {
+	EventStore
+	QueryStore
+	RunStore
+	LeaseStore
+
+	// InitializeDatabase brings the schema up to date based on mode
+	InitializeDatabase(ctx context.Context, mode InitMode) error
+	HealthCheck(ctx context.Context) error
+	Close() error
+}
+
+// Migratable is implemented by stores whose schema is managed by versioned
+// migrations
+type Migratable interface {
+	Migrator() (*Migrator, error)
+}
+
+var (
+	_ Store      = (*VerticaDB)(nil)
+	_ Migratable = (*VerticaDB)(nil)
+	_ Migratable = (*PostgresDB)(nil)
+)
+
+// Open connects to the backend selected by cfg.Driver
+func Open(cfg *config.DatabaseConfig, logger *logrus.Logger) (Store, error) {
+	switch cfg.Driver {
+	case "", config.DriverVertica:
+		db, err := NewVerticaDB(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case config.DriverPostgres:
+		db, err := NewPostgresDB(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case config.DriverSQLite:
+		db, err := NewSQLiteDB(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"nasa-data-hub-etl/pkg/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// lookupChunkSize limits the number of ids in a single IN (...) lookup
+const lookupChunkSize = 500
+
+// UpsertResult summarizes the outcome of a batch upsert
+type UpsertResult struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Total returns the number of records in the batch
+func (r UpsertResult) Total() int {
+	return r.Inserted + r.Updated + r.Unchanged
+}
+
+// Attributes describes the result on a trace span
+func (r UpsertResult) Attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("etl.records.inserted", r.Inserted),
+		attribute.Int("etl.records.updated", r.Updated),
+		attribute.Int("etl.records.unchanged", r.Unchanged),
+	}
+}
+
+// Add returns the sum of two results
+func (r UpsertResult) Add(other UpsertResult) UpsertResult {
+	return UpsertResult{
+		Inserted:  r.Inserted + other.Inserted,
+		Updated:   r.Updated + other.Updated,
+		Unchanged: r.Unchanged + other.Unchanged,
+	}
+}
+
+// classifyEvents splits a batch into records to insert and records to update,
+// given the rows already stored under the same ids and the ids that already
+// have child rows. Records whose content did not change are only counted,
+// unless their child rows are missing, e.g. because they were loaded before
+// the child tables existed. If the batch holds an id more than once the last
+// occurrence wins.
+func classifyEvents(existing map[string]*models.EventRecord, withChildren map[string]bool, events []*models.EventRecord) (inserts, updates []*models.EventRecord, result UpsertResult) {
+	for _, event := range dedupeEvents(events) {
+		current, ok := existing[event.ID]
+		switch {
+		case !ok:
+			inserts = append(inserts, event)
+			result.Inserted++
+		case !sameEventContent(current, event) || (!withChildren[event.ID] && hasChildRows(event)):
+			updates = append(updates, event)
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return inserts, updates, result
+}
+
+// classifyCategories is the category counterpart of classifyEvents
+func classifyCategories(existing map[string]*models.CategoryRecord, categories []*models.CategoryRecord) (inserts, updates []*models.CategoryRecord, result UpsertResult) {
+	for _, category := range dedupeCategories(categories) {
+		current, ok := existing[category.ID]
+		switch {
+		case !ok:
+			inserts = append(inserts, category)
+			result.Inserted++
+		case !sameCategoryContent(current, category):
+			updates = append(updates, category)
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return inserts, updates, result
+}
+
+// dedupeEvents keeps the last record for every id, preserving first-seen order
+func dedupeEvents(events []*models.EventRecord) []*models.EventRecord {
+	index := make(map[string]int, len(events))
+	unique := make([]*models.EventRecord, 0, len(events))
+	for _, event := range events {
+		if i, ok := index[event.ID]; ok {
+			unique[i] = event
+			continue
+		}
+		index[event.ID] = len(unique)
+		unique = append(unique, event)
+	}
+	return unique
+}
+
+// dedupeCategories keeps the last record for every id, preserving first-seen order
+func dedupeCategories(categories []*models.CategoryRecord) []*models.CategoryRecord {
+	index := make(map[string]int, len(categories))
+	unique := make([]*models.CategoryRecord, 0, len(categories))
+	for _, category := range categories {
+		if i, ok := index[category.ID]; ok {
+			unique[i] = category
+			continue
+		}
+		index[category.ID] = len(unique)
+		unique = append(unique, category)
+	}
+	return unique
+}
+
+// sameEventContent compares the columns an upsert would overwrite
+func sameEventContent(a, b *models.EventRecord) bool {
+	return a.Title == b.Title &&
+		a.Description == b.Description &&
+		a.Link == b.Link &&
+		a.Categories == b.Categories &&
+		a.Sources == b.Sources &&
+		a.Geometry == b.Geometry &&
+		equalStringPtr(a.Closed, b.Closed)
+}
+
+// hasChildRows reports whether an event has rows for the normalized child tables
+func hasChildRows(event *models.EventRecord) bool {
+	return len(event.CategoryIDs) > 0 || len(event.SourceLinks) > 0 || len(event.Geometries) > 0
+}
+
+// sameCategoryContent compares the columns an upsert would overwrite
+func sameCategoryContent(a, b *models.CategoryRecord) bool {
+	return a.Title == b.Title &&
+		a.Link == b.Link &&
+		a.Description == b.Description &&
+		a.Layers == b.Layers
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// loadExistingEvents returns the stored rows for the ids in the batch
+func loadExistingEvents(ctx context.Context, tx *sql.Tx, events []*models.EventRecord) (map[string]*models.EventRecord, error) {
+	existing := make(map[string]*models.EventRecord, len(events))
+
+	for start := 0; start < len(events); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(events))
+
+		args := make([]interface{}, 0, end-start)
+		for _, event := range events[start:end] {
+			args = append(args, event.ID)
+		}
+
+		query := fmt.Sprintf(`
+			SELECT id, title, description, link, categories, sources, geometry, closed
+			FROM events
+			WHERE id IN (%s)
+		`, placeholders(len(args)))
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query existing events: %w", err)
+		}
+
+		for rows.Next() {
+			var record models.EventRecord
+			var title, description, link, categories, sources, geometry, closed sql.NullString
+			if err := rows.Scan(&record.ID, &title, &description, &link, &categories, &sources, &geometry, &closed); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan existing event: %w", err)
+			}
+			record.Title = title.String
+			record.Description = description.String
+			record.Link = link.String
+			record.Categories = categories.String
+			record.Sources = sources.String
+			record.Geometry = geometry.String
+			if closed.Valid {
+				record.Closed = &closed.String
+			}
+			existing[record.ID] = &record
+		}
+
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("failed to read existing events: %w", err)
+		}
+	}
+
+	return existing, nil
+}
+
+// loadEventsWithChildren returns the ids in the batch that already have rows
+// in any of the child tables
+func loadEventsWithChildren(ctx context.Context, tx *sql.Tx, events []*models.EventRecord) (map[string]bool, error) {
+	withChildren := make(map[string]bool, len(events))
+
+	for start := 0; start < len(events); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(events))
+
+		ids := make([]interface{}, 0, end-start)
+		for _, event := range events[start:end] {
+			ids = append(ids, event.ID)
+		}
+
+		in := placeholders(len(ids))
+		query := fmt.Sprintf(`
+			SELECT event_id FROM event_geometries WHERE event_id IN (%s)
+			UNION
+			SELECT event_id FROM event_sources WHERE event_id IN (%s)
+			UNION
+			SELECT event_id FROM event_categories WHERE event_id IN (%s)
+		`, in, in, in)
+
+		args := make([]interface{}, 0, 3*len(ids))
+		args = append(append(append(args, ids...), ids...), ids...)
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query event child rows: %w", err)
+		}
+
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan event child row: %w", err)
+			}
+			withChildren[id] = true
+		}
+
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("failed to read event child rows: %w", err)
+		}
+	}
+
+	return withChildren, nil
+}
+
+// loadExistingCategories returns the stored rows for the ids in the batch
+func loadExistingCategories(ctx context.Context, tx *sql.Tx, categories []*models.CategoryRecord) (map[string]*models.CategoryRecord, error) {
+	existing := make(map[string]*models.CategoryRecord, len(categories))
+
+	for start := 0; start < len(categories); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(categories))
+
+		args := make([]interface{}, 0, end-start)
+		for _, category := range categories[start:end] {
+			args = append(args, category.ID)
+		}
+
+		query := fmt.Sprintf(`
+			SELECT id, title, link, description, layers
+			FROM categories
+			WHERE id IN (%s)
+		`, placeholders(len(args)))
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query existing categories: %w", err)
+		}
+
+		for rows.Next() {
+			var record models.CategoryRecord
+			var title, link, description, layers sql.NullString
+			if err := rows.Scan(&record.ID, &title, &link, &description, &layers); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan existing category: %w", err)
+			}
+			record.Title = title.String
+			record.Link = link.String
+			record.Description = description.String
+			record.Layers = layers.String
+			existing[record.ID] = &record
+		}
+
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("failed to read existing categories: %w", err)
+		}
+	}
+
+	return existing, nil
+}
+
+// placeholders returns n comma separated '?' placeholders
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
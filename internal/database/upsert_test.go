@@ -0,0 +1,113 @@
+package database
+
+import (
+	"testing"
+
+	"nasa-data-hub-etl/pkg/models"
+)
+
+func TestClassifyEvents(t *testing.T) {
+	closed := "2025-01-20T00:00:00Z"
+	existing := map[string]*models.EventRecord{
+		"EONET_1": {ID: "EONET_1", Title: "Wildfire A", Geometry: `[]`},
+		"EONET_2": {ID: "EONET_2", Title: "Storm B", Geometry: `[]`},
+	}
+
+	events := []*models.EventRecord{
+		{ID: "EONET_1", Title: "Wildfire A", Geometry: `[]`},               // unchanged
+		{ID: "EONET_2", Title: "Storm B", Geometry: `[]`, Closed: &closed}, // closed since last run
+		{ID: "EONET_3", Title: "Volcano C", Geometry: `[]`},                // new
+		{ID: "EONET_3", Title: "Volcano C (updated)", Geometry: `[]`},      // duplicate in batch
+	}
+
+	inserts, updates, result := classifyEvents(existing, nil, events)
+
+	if result.Inserted != 1 || result.Updated != 1 || result.Unchanged != 1 {
+		t.Errorf("classifyEvents() result = %+v, want 1 inserted, 1 updated, 1 unchanged", result)
+	}
+	if result.Total() != 3 {
+		t.Errorf("Total() = %d, want 3", result.Total())
+	}
+
+	if len(inserts) != 1 || inserts[0].Title != "Volcano C (updated)" {
+		t.Errorf("inserts = %+v, want the last occurrence of EONET_3", inserts)
+	}
+	if len(updates) != 1 || updates[0].ID != "EONET_2" {
+		t.Errorf("updates = %+v, want EONET_2", updates)
+	}
+}
+
+func TestClassifyEvents_MissingChildRows(t *testing.T) {
+	existing := map[string]*models.EventRecord{
+		"EONET_1": {ID: "EONET_1", Title: "Wildfire A"},
+		"EONET_2": {ID: "EONET_2", Title: "Storm B"},
+		"EONET_3": {ID: "EONET_3", Title: "Volcano C"},
+	}
+	withChildren := map[string]bool{"EONET_1": true}
+
+	events := []*models.EventRecord{
+		{ID: "EONET_1", Title: "Wildfire A", CategoryIDs: []string{"wildfires"}}, // normalized already
+		{ID: "EONET_2", Title: "Storm B", CategoryIDs: []string{"severeStorms"}}, // loaded before child tables
+		{ID: "EONET_3", Title: "Volcano C"},                                      // nothing to normalize
+	}
+
+	_, updates, result := classifyEvents(existing, withChildren, events)
+
+	if result != (UpsertResult{Updated: 1, Unchanged: 2}) {
+		t.Errorf("classifyEvents() result = %+v, want 1 updated, 2 unchanged", result)
+	}
+	if len(updates) != 1 || updates[0].ID != "EONET_2" {
+		t.Errorf("updates = %+v, want EONET_2", updates)
+	}
+}
+
+func TestClassifyCategories(t *testing.T) {
+	existing := map[string]*models.CategoryRecord{
+		"wildfires":    {ID: "wildfires", Title: "Wildfires", Description: "Fires"},
+		"severeStorms": {ID: "severeStorms", Title: "Severe Storms"},
+	}
+
+	categories := []*models.CategoryRecord{
+		{ID: "wildfires", Title: "Wildfires", Description: "Wildland fires"},
+		{ID: "severeStorms", Title: "Severe Storms"},
+		{ID: "volcanoes", Title: "Volcanoes"},
+	}
+
+	inserts, updates, result := classifyCategories(existing, categories)
+
+	if result != (UpsertResult{Inserted: 1, Updated: 1, Unchanged: 1}) {
+		t.Errorf("classifyCategories() result = %+v", result)
+	}
+	if len(inserts) != 1 || inserts[0].ID != "volcanoes" {
+		t.Errorf("inserts = %+v, want category volcanoes", inserts)
+	}
+	if len(updates) != 1 || updates[0].ID != "wildfires" {
+		t.Errorf("updates = %+v, want category wildfires", updates)
+	}
+}
+
+func TestUpsertResult_Add(t *testing.T) {
+	a := UpsertResult{Inserted: 1, Updated: 2, Unchanged: 3}
+	b := UpsertResult{Inserted: 4, Updated: 5, Unchanged: 6}
+
+	if got := a.Add(b); got != (UpsertResult{Inserted: 5, Updated: 7, Unchanged: 9}) {
+		t.Errorf("Add() = %+v", got)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: ""},
+		{n: 1, want: "?"},
+		{n: 3, want: "?, ?, ?"},
+	}
+
+	for _, tt := range tests {
+		if got := placeholders(tt.n); got != tt.want {
+			t.Errorf("placeholders(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
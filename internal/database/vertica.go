@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/tracing"
 	"nasa-data-hub-etl/pkg/models"
 
 	"github.com/sirupsen/logrus"
 	_ "github.com/vertica/vertica-sql-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // VerticaDB handles database operations for VerticaDB
@@ -20,18 +23,14 @@ type VerticaDB struct {
 	logger *logrus.Logger
 }
 
+// log returns the logger with the correlation fields carried by ctx
+func (v *VerticaDB) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, v.logger)
+}
+
 // NewVerticaDB creates a new VerticaDB connection
 func NewVerticaDB(cfg *config.DatabaseConfig, logger *logrus.Logger) (*VerticaDB, error) {
-	dsn := fmt.Sprintf("vertica://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Database,
-		cfg.SSLMode,
-	)
-
-	db, err := sql.Open("vertica", dsn)
+	db, err := sql.Open("vertica", cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -52,59 +51,9 @@ func NewVerticaDB(cfg *config.DatabaseConfig, logger *logrus.Logger) (*VerticaDB
 		logger: logger,
 	}
 
-	// Initialize database schema
-	if err := verticaDB.InitializeSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
-	}
-
 	return verticaDB, nil
 }
 
-// InitializeSchema creates the necessary tables if they don't exist
-func (v *VerticaDB) InitializeSchema() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS categories (
-			id INTEGER PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			link VARCHAR(500),
-			description VARCHAR(10000),
-			layers VARCHAR(255),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS events (
-			id VARCHAR(50) PRIMARY KEY,
-			title VARCHAR(500) NOT NULL,
-			description VARCHAR(10000),
-			link VARCHAR(500),
-			categories VARCHAR(10000),
-			sources VARCHAR(10000),
-			geometry VARCHAR(10000),
-			closed VARCHAR(50),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS etl_runs (
-			id BIGINT PRIMARY KEY,
-			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			completed_at TIMESTAMP,
-			status VARCHAR(20) NOT NULL,
-			events_processed INTEGER DEFAULT 0,
-			categories_processed INTEGER DEFAULT 0,
-			error_message VARCHAR(10000)
-		)`,
-	}
-
-	for _, query := range queries {
-		if _, err := v.db.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute schema query: %w", err)
-		}
-	}
-
-	v.logger.Info("Database schema initialized successfully")
-	return nil
-}
-
 // InsertEvent inserts an event record
 func (v *VerticaDB) InsertEvent(ctx context.Context, event *models.EventRecord) error {
 	query := `
@@ -152,25 +101,132 @@ func (v *VerticaDB) InsertCategory(ctx context.Context, category *models.Categor
 	return nil
 }
 
-// BatchInsertEvents inserts multiple events in a batch
-func (v *VerticaDB) BatchInsertEvents(ctx context.Context, events []*models.EventRecord) error {
+// UpsertEvents merges a batch of events into the events table. New events are
+// inserted, events whose content changed are updated (keeping created_at) and
+// identical events are left untouched. Batches of at least bulkLoadMinRows
+// changed events are loaded with COPY through staging tables, falling back to
+// one MERGE per row when COPY is not available.
+func (v *VerticaDB) UpsertEvents(ctx context.Context, events []*models.EventRecord) (result UpsertResult, err error) {
+	ctx, span := tracing.Start(ctx, "vertica.upsert_events", attribute.Int("etl.records", len(events)))
+	defer func() {
+		span.SetAttributes(result.Attributes()...)
+		tracing.End(span, err)
+	}()
+
 	if len(events) == 0 {
-		return nil
+		return UpsertResult{}, nil
 	}
 
-	tx, err := v.db.BeginTx(ctx, nil)
+	conn, err := v.db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return UpsertResult{}, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	bulk := len(events) >= bulkLoadMinRows
+	if bulk {
+		if err := createStagingTables(ctx, conn); err != nil {
+			v.log(ctx).WithError(err).Warn("COPY staging tables unavailable, falling back to row merge")
+			bulk = false
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
-		if err := tx.Rollback(); err != nil {
-			v.logger.WithError(err).Error("Failed to rollback transaction")
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			v.log(ctx).WithError(err).Error("Failed to rollback transaction")
 		}
 	}()
 
+	existing, err := loadExistingEvents(ctx, tx, events)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	withChildren, err := loadEventsWithChildren(ctx, tx, events)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	inserts, updates, result := classifyEvents(existing, withChildren, events)
+	writes := append(inserts, updates...)
+
+	start := time.Now()
+	path := loadPathMerge
+	if bulk && len(writes) >= bulkLoadMinRows {
+		copied, err := v.tryCopyEvents(ctx, tx, writes)
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		if copied {
+			path = loadPathCopy
+		}
+	}
+	if path == loadPathMerge {
+		if err := mergeEvents(ctx, tx, writes); err != nil {
+			return UpsertResult{}, err
+		}
+	}
+	span.SetAttributes(attribute.String("etl.load_path", path))
+
+	if err := tx.Commit(); err != nil {
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	elapsed := time.Since(start)
+	fields := logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+		"path":      path,
+		"duration":  elapsed,
+	}
+	if len(writes) > 0 && elapsed > 0 {
+		fields["rows_per_second"] = int(float64(len(writes)) / elapsed.Seconds())
+	}
+	v.log(ctx).WithFields(fields).Info("Successfully upserted events")
+	return result, nil
+}
+
+// tryCopyEvents loads events with copyEvents inside a savepoint. It reports
+// false after rolling back to the savepoint if COPY failed, so the caller can
+// merge the rows one by one instead.
+func (v *VerticaDB) tryCopyEvents(ctx context.Context, tx *sql.Tx, events []*models.EventRecord) (bool, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT event_copy`); err != nil {
+		return false, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if err := copyEvents(ctx, tx, events); err != nil {
+		v.log(ctx).WithError(err).Warn("COPY bulk load failed, falling back to row merge")
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT event_copy`); err != nil {
+			return false, fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// mergeEvents merges events one MERGE per row and replaces their child rows
+func mergeEvents(ctx context.Context, tx *sql.Tx, events []*models.EventRecord) error {
 	query := `
-		INSERT INTO events (id, title, description, link, categories, sources, geometry, closed, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		MERGE INTO events t
+		USING (SELECT ? AS id, ? AS title, ? AS description, ? AS link, ? AS categories, ? AS sources, ? AS geometry, ? AS closed) s
+		ON t.id = s.id
+		WHEN MATCHED THEN UPDATE SET
+			title = s.title,
+			description = s.description,
+			link = s.link,
+			categories = s.categories,
+			sources = s.sources,
+			geometry = s.geometry,
+			closed = s.closed,
+			updated_at = CURRENT_TIMESTAMP
+		WHEN NOT MATCHED THEN INSERT (id, title, description, link, categories, sources, geometry, closed, created_at, updated_at)
+			VALUES (s.id, s.title, s.description, s.link, s.categories, s.sources, s.geometry, s.closed, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
@@ -179,6 +235,12 @@ func (v *VerticaDB) BatchInsertEvents(ctx context.Context, events []*models.Even
 	}
 	defer stmt.Close()
 
+	children, err := prepareEventChildStatements(ctx, tx)
+	if err != nil {
+		return err
+	}
+	defer children.Close()
+
 	for _, event := range events {
 		_, err := stmt.ExecContext(ctx,
 			event.ID,
@@ -191,46 +253,167 @@ func (v *VerticaDB) BatchInsertEvents(ctx context.Context, events []*models.Even
 			event.Closed,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to execute batch insert: %w", err)
+			return fmt.Errorf("failed to merge event %s: %w", event.ID, err)
+		}
+
+		if err := children.replace(ctx, event); err != nil {
+			return err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return nil
+}
+
+// eventChildStatements holds the prepared statements that replace the rows of
+// the child tables of an event
+type eventChildStatements struct {
+	deletes        []*sql.Stmt
+	insertGeometry *sql.Stmt
+	insertSource   *sql.Stmt
+	insertCategory *sql.Stmt
+}
+
+// prepareEventChildStatements prepares the child table statements in tx
+func prepareEventChildStatements(ctx context.Context, tx *sql.Tx) (*eventChildStatements, error) {
+	s := &eventChildStatements{}
+
+	prepare := func(query string) (*sql.Stmt, error) {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		return stmt, nil
+	}
+
+	for _, table := range []string{"event_geometries", "event_sources", "event_categories"} {
+		stmt, err := prepare(fmt.Sprintf(`DELETE FROM %s WHERE event_id = ?`, table))
+		if err != nil {
+			return nil, err
+		}
+		s.deletes = append(s.deletes, stmt)
+	}
+
+	var err error
+	if s.insertGeometry, err = prepare(`
+		INSERT INTO event_geometries (event_id, seq, observed_at, geometry_type, longitude, latitude, magnitude_value, magnitude_unit, magnitude_description, coordinates,
+			min_lon, min_lat, max_lon, max_lat)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`); err != nil {
+		return nil, err
+	}
+	if s.insertSource, err = prepare(`INSERT INTO event_sources (event_id, source_id, url) VALUES (?, ?, ?)`); err != nil {
+		return nil, err
+	}
+	if s.insertCategory, err = prepare(`INSERT INTO event_categories (event_id, category_id) VALUES (?, ?)`); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// replace deletes the child rows of an event and inserts the current ones
+func (s *eventChildStatements) replace(ctx context.Context, event *models.EventRecord) error {
+	for _, stmt := range s.deletes {
+		if _, err := stmt.ExecContext(ctx, event.ID); err != nil {
+			return fmt.Errorf("failed to delete child rows of event %s: %w", event.ID, err)
+		}
+	}
+
+	for _, g := range event.Geometries {
+		envelope := geometryEnvelope(g)
+		_, err := s.insertGeometry.ExecContext(ctx,
+			event.ID,
+			g.Seq,
+			g.ObservedAt.UTC(),
+			g.Type,
+			g.Longitude,
+			g.Latitude,
+			g.MagnitudeValue,
+			g.MagnitudeUnit,
+			g.MagnitudeDescription,
+			g.Coordinates,
+			envelope[0], envelope[1], envelope[2], envelope[3],
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert geometry %d of event %s: %w", g.Seq, event.ID, err)
+		}
+	}
+
+	for _, source := range event.SourceLinks {
+		if _, err := s.insertSource.ExecContext(ctx, event.ID, source.SourceID, source.URL); err != nil {
+			return fmt.Errorf("failed to insert source %s of event %s: %w", source.SourceID, event.ID, err)
+		}
+	}
+
+	for _, categoryID := range event.CategoryIDs {
+		if _, err := s.insertCategory.ExecContext(ctx, event.ID, categoryID); err != nil {
+			return fmt.Errorf("failed to insert category %s of event %s: %w", categoryID, event.ID, err)
+		}
 	}
 
-	v.logger.WithField("count", len(events)).Info("Successfully batch inserted events")
 	return nil
 }
 
-// BatchInsertCategories inserts multiple categories in a batch
-func (v *VerticaDB) BatchInsertCategories(ctx context.Context, categories []*models.CategoryRecord) error {
+// Close closes the prepared statements
+func (s *eventChildStatements) Close() {
+	for _, stmt := range append(s.deletes, s.insertGeometry, s.insertSource, s.insertCategory) {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// UpsertCategories merges a batch of categories into the categories table
+func (v *VerticaDB) UpsertCategories(ctx context.Context, categories []*models.CategoryRecord) (result UpsertResult, err error) {
+	ctx, span := tracing.Start(ctx, "vertica.upsert_categories", attribute.Int("etl.records", len(categories)))
+	defer func() {
+		span.SetAttributes(result.Attributes()...)
+		tracing.End(span, err)
+	}()
+
 	if len(categories) == 0 {
-		return nil
+		return UpsertResult{}, nil
 	}
 
 	tx, err := v.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
-		if err := tx.Rollback(); err != nil {
-			v.logger.WithError(err).Error("Failed to rollback transaction")
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			v.log(ctx).WithError(err).Error("Failed to rollback transaction")
 		}
 	}()
 
+	existing, err := loadExistingCategories(ctx, tx, categories)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	inserts, updates, result := classifyCategories(existing, categories)
+
 	query := `
-		INSERT INTO categories (id, title, link, description, layers, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		MERGE INTO categories t
+		USING (SELECT ? AS id, ? AS title, ? AS link, ? AS description, ? AS layers) s
+		ON t.id = s.id
+		WHEN MATCHED THEN UPDATE SET
+			title = s.title,
+			link = s.link,
+			description = s.description,
+			layers = s.layers,
+			updated_at = CURRENT_TIMESTAMP
+		WHEN NOT MATCHED THEN INSERT (id, title, link, description, layers, created_at, updated_at)
+			VALUES (s.id, s.title, s.link, s.description, s.layers, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, category := range categories {
+	for _, category := range append(inserts, updates...) {
 		_, err := stmt.ExecContext(ctx,
 			category.ID,
 			category.Title,
@@ -239,46 +422,91 @@ func (v *VerticaDB) BatchInsertCategories(ctx context.Context, categories []*mod
 			category.Layers,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to execute batch insert: %w", err)
+			return UpsertResult{}, fmt.Errorf("failed to merge category %s: %w", category.ID, err)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	v.logger.WithField("count", len(categories)).Info("Successfully batch inserted categories")
-	return nil
+	v.log(ctx).WithFields(logrus.Fields{
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+	}).Info("Successfully upserted categories")
+	return result, nil
+}
+
+// ListEvents returns the events matching filter ordered by id
+func (v *VerticaDB) ListEvents(ctx context.Context, filter EventFilter) ([]*models.EventRecord, error) {
+	return sqlReader{db: v.db}.listEvents(ctx, filter)
+}
+
+// CountEvents counts the events matching filter
+func (v *VerticaDB) CountEvents(ctx context.Context, filter EventFilter) (int64, error) {
+	return sqlReader{db: v.db}.countEvents(ctx, filter)
 }
 
-// StartETLRun records the start of an ETL run
-func (v *VerticaDB) StartETLRun(ctx context.Context) (int64, error) {
-	// VerticaDB doesn't support RETURNING clause, so we'll use a different approach
-	// For now, we'll return a timestamp-based ID
-	timestamp := time.Now().Unix()
+// GetEvent returns an event with its child rows, or nil if it does not exist
+func (v *VerticaDB) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	return sqlReader{db: v.db}.getEvent(ctx, id)
+}
 
-	query := `INSERT INTO etl_runs (id, status) VALUES (?, 'running')`
-	_, err := v.db.ExecContext(ctx, query, timestamp)
+// ListCategories returns all categories ordered by id
+func (v *VerticaDB) ListCategories(ctx context.Context) ([]*models.CategoryRecord, error) {
+	return sqlReader{db: v.db}.listCategories(ctx)
+}
+
+// StartETLRun records the start of an ETL run with an id from etl_runs_id_seq
+func (v *VerticaDB) StartETLRun(ctx context.Context, run ETLRunStart) (int64, error) {
+	// VerticaDB doesn't support a RETURNING clause, so draw the id first
+	var id int64
+	if err := v.db.QueryRowContext(ctx, `SELECT NEXTVAL('etl_runs_id_seq')`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to allocate ETL run id: %w", err)
+	}
+
+	query := `
+		INSERT INTO etl_runs (id, status, heartbeat_at, app_version, git_commit, config_hash)
+		VALUES (?, 'running', CURRENT_TIMESTAMP, ?, ?, ?)
+	`
+	_, err := v.db.ExecContext(ctx, query, id, run.AppVersion, run.GitCommit, run.ConfigHash)
 	if err != nil {
 		return 0, fmt.Errorf("failed to start ETL run: %w", err)
 	}
 
-	return timestamp, nil
+	return id, nil
 }
 
 // CompleteETLRun records the completion of an ETL run
-func (v *VerticaDB) CompleteETLRun(ctx context.Context, runID int64, status string, eventsProcessed, categoriesProcessed int, errorMsg *string) error {
+func (v *VerticaDB) CompleteETLRun(ctx context.Context, runID int64, result ETLRunResult) error {
 	query := `
-		UPDATE etl_runs 
+		UPDATE etl_runs
 		SET completed_at = CURRENT_TIMESTAMP,
 			status = ?,
 			events_processed = ?,
 			categories_processed = ?,
-			error_message = ?
+			error_message = ?,
+			extract_duration_ms = ?,
+			transform_duration_ms = ?,
+			load_duration_ms = ?,
+			api_requests = ?,
+			bytes_downloaded = ?
 		WHERE id = ?
 	`
 
-	_, err := v.db.ExecContext(ctx, query, status, eventsProcessed, categoriesProcessed, errorMsg, runID)
+	_, err := v.db.ExecContext(ctx, query,
+		result.Status,
+		result.EventsProcessed,
+		result.CategoriesProcessed,
+		result.ErrorMessage,
+		result.ExtractDurationMs,
+		result.TransformDurationMs,
+		result.LoadDurationMs,
+		result.APIRequests,
+		result.BytesDownloaded,
+		runID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to complete ETL run: %w", err)
 	}
@@ -286,20 +514,267 @@ func (v *VerticaDB) CompleteETLRun(ctx context.Context, runID int64, status stri
 	return nil
 }
 
+// HeartbeatETLRun records that a running run is still alive
+func (v *VerticaDB) HeartbeatETLRun(ctx context.Context, runID int64) error {
+	query := `UPDATE etl_runs SET heartbeat_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'running'`
+	if _, err := v.db.ExecContext(ctx, query, runID); err != nil {
+		return fmt.Errorf("failed to record ETL run heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// AbandonStaleETLRuns marks running runs without a heartbeat within timeout as abandoned
+func (v *VerticaDB) AbandonStaleETLRuns(ctx context.Context, timeout time.Duration) (int64, error) {
+	query := `
+		UPDATE etl_runs
+		SET status = 'abandoned',
+			completed_at = CURRENT_TIMESTAMP,
+			error_message = ?
+		WHERE status = 'running'
+			AND COALESCE(heartbeat_at, started_at) < CURRENT_TIMESTAMP - INTERVAL '1 second' * ?
+	`
+
+	res, err := v.db.ExecContext(ctx, query, abandonedMessage(timeout), int64(timeout/time.Second))
+	if err != nil {
+		return 0, fmt.Errorf("failed to abandon stale ETL runs: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// AcquireLease takes or renews a lease. The lease row is created expired if
+// missing, then claimed with a guarded UPDATE. Vertica serializes UPDATEs on
+// a table, so only one of several concurrent claims matches the row.
+func (v *VerticaDB) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	_, err := v.db.ExecContext(ctx, `
+		MERGE INTO etl_locks t
+		USING (SELECT ? AS name) s
+		ON t.name = s.name
+		WHEN NOT MATCHED THEN INSERT (name, owner, expires_at)
+			VALUES (s.name, '', TIMESTAMP '1970-01-01 00:00:00')
+	`, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to create lease %s: %w", name, err)
+	}
+
+	query := `
+		UPDATE etl_locks
+		SET acquired_at = CASE WHEN owner = ? THEN acquired_at ELSE CURRENT_TIMESTAMP END,
+			owner = ?,
+			renewed_at = CURRENT_TIMESTAMP,
+			expires_at = CURRENT_TIMESTAMP + INTERVAL '1 second' * ?
+		WHERE name = ?
+			AND (owner = ? OR expires_at < CURRENT_TIMESTAMP)
+	`
+
+	res, err := v.db.ExecContext(ctx, query, owner, owner, int64(ttl/time.Second), name, owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease expires the lease if owner holds it
+func (v *VerticaDB) ReleaseLease(ctx context.Context, name, owner string) error {
+	query := `UPDATE etl_locks SET expires_at = CURRENT_TIMESTAMP WHERE name = ? AND owner = ?`
+	if _, err := v.db.ExecContext(ctx, query, name, owner); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+
+	return nil
+}
+
 // GetLastETLRun returns information about the last ETL run
 func (v *VerticaDB) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
+	query := `SELECT ` + etlRunColumns + ` FROM etl_runs ORDER BY started_at DESC, id DESC LIMIT 1`
+
+	run, err := scanETLRun(v.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No previous runs
+		}
+		return nil, fmt.Errorf("failed to get last ETL run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ListETLRuns returns runs, most recently started first
+func (v *VerticaDB) ListETLRuns(ctx context.Context, limit, offset int) ([]*ETLRunInfo, error) {
+	return sqlReader{db: v.db}.listETLRuns(ctx, limit, offset)
+}
+
+// GetETLRun returns a run, or nil if it does not exist
+func (v *VerticaDB) GetETLRun(ctx context.Context, id int64) (*ETLRunInfo, error) {
+	return sqlReader{db: v.db}.getETLRun(ctx, id)
+}
+
+// GetWatermark returns the high-water mark of a source, or nil if none was recorded
+func (v *VerticaDB) GetWatermark(ctx context.Context, source string) (*time.Time, error) {
+	var watermark time.Time
+	err := v.db.QueryRowContext(ctx, `SELECT watermark FROM etl_watermarks WHERE source = ?`, source).Scan(&watermark)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get watermark: %w", err)
+	}
+
+	return &watermark, nil
+}
+
+// SetWatermark records the high-water mark of a source
+func (v *VerticaDB) SetWatermark(ctx context.Context, source string, watermark time.Time) error {
+	query := `
+		MERGE INTO etl_watermarks t
+		USING (SELECT ? AS source, ? AS watermark) s
+		ON t.source = s.source
+		WHEN MATCHED THEN UPDATE SET watermark = s.watermark, updated_at = CURRENT_TIMESTAMP
+		WHEN NOT MATCHED THEN INSERT (source, watermark, updated_at) VALUES (s.source, s.watermark, CURRENT_TIMESTAMP)
+	`
+
+	if _, err := v.db.ExecContext(ctx, query, source, watermark.UTC()); err != nil {
+		return fmt.Errorf("failed to set watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ResetWatermark removes the high-water mark of a source
+func (v *VerticaDB) ResetWatermark(ctx context.Context, source string) error {
+	if _, err := v.db.ExecContext(ctx, `DELETE FROM etl_watermarks WHERE source = ?`, source); err != nil {
+		return fmt.Errorf("failed to reset watermark: %w", err)
+	}
+
+	return nil
+}
+
+// ListBackfillChunks returns the recorded chunks that lie within [from, to)
+func (v *VerticaDB) ListBackfillChunks(ctx context.Context, from, to time.Time) ([]BackfillChunk, error) {
+	query := `
+		SELECT chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at
+		FROM etl_backfill_chunks
+		WHERE chunk_start >= ? AND chunk_end <= ?
+		ORDER BY chunk_start
+	`
+
+	rows, err := v.db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []BackfillChunk
+	for rows.Next() {
+		var chunk BackfillChunk
+		var errorMsg sql.NullString
+		var completedAt sql.NullTime
+		if err := rows.Scan(&chunk.Start, &chunk.End, &chunk.Status, &chunk.EventsLoaded, &errorMsg, &chunk.StartedAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill chunk: %w", err)
+		}
+		if errorMsg.Valid {
+			chunk.ErrorMessage = &errorMsg.String
+		}
+		if completedAt.Valid {
+			chunk.CompletedAt = &completedAt.Time
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, rows.Err()
+}
+
+// SaveBackfillChunk records the progress of a backfill chunk
+func (v *VerticaDB) SaveBackfillChunk(ctx context.Context, chunk *BackfillChunk) error {
 	query := `
-		SELECT id, started_at, completed_at, status, events_processed, categories_processed, error_message
-		FROM etl_runs
-		ORDER BY started_at DESC
-		LIMIT 1
+		MERGE INTO etl_backfill_chunks t
+		USING (SELECT ? AS chunk_start, ? AS chunk_end, ? AS status, ? AS events_loaded, ? AS error_message, ? AS completed_at) s
+		ON t.chunk_start = s.chunk_start AND t.chunk_end = s.chunk_end
+		WHEN MATCHED THEN UPDATE SET
+			status = s.status,
+			events_loaded = s.events_loaded,
+			error_message = s.error_message,
+			completed_at = s.completed_at
+		WHEN NOT MATCHED THEN INSERT (chunk_start, chunk_end, status, events_loaded, error_message, started_at, completed_at)
+			VALUES (s.chunk_start, s.chunk_end, s.status, s.events_loaded, s.error_message, CURRENT_TIMESTAMP, s.completed_at)
 	`
 
+	_, err := v.db.ExecContext(ctx, query,
+		chunk.Start.UTC(),
+		chunk.End.UTC(),
+		chunk.Status,
+		chunk.EventsLoaded,
+		chunk.ErrorMessage,
+		chunk.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save backfill chunk: %w", err)
+	}
+
+	return nil
+}
+
+// BackfillChunk represents the progress of one date range of a backfill
+type BackfillChunk struct {
+	Start        time.Time  `json:"start"`
+	End          time.Time  `json:"end"` // Exclusive
+	Status       string     `json:"status"`
+	EventsLoaded int        `json:"events_loaded"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// ETLRunInfo represents information about an ETL run
+type ETLRunInfo struct {
+	ID                  int64      `json:"id"`
+	StartedAt           time.Time  `json:"started_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	Status              string     `json:"status"`
+	EventsProcessed     int        `json:"events_processed"`
+	CategoriesProcessed int        `json:"categories_processed"`
+	ErrorMessage        *string    `json:"error_message,omitempty"`
+	ExtractDurationMs   int64      `json:"extract_duration_ms"`
+	TransformDurationMs int64      `json:"transform_duration_ms"`
+	LoadDurationMs      int64      `json:"load_duration_ms"`
+	APIRequests         int64      `json:"api_requests"`
+	BytesDownloaded     int64      `json:"bytes_downloaded"`
+	AppVersion          string     `json:"app_version,omitempty"`
+	GitCommit           string     `json:"git_commit,omitempty"`
+	ConfigHash          string     `json:"config_hash,omitempty"`
+	HeartbeatAt         *time.Time `json:"heartbeat_at,omitempty"`
+}
+
+// etlRunColumns are the etl_runs columns read by scanETLRun. Runs recorded
+// before the detail columns existed read as zero values.
+const etlRunColumns = `id, started_at, completed_at, status, events_processed, categories_processed, error_message,
+	COALESCE(extract_duration_ms, 0), COALESCE(transform_duration_ms, 0), COALESCE(load_duration_ms, 0),
+	COALESCE(api_requests, 0), COALESCE(bytes_downloaded, 0),
+	COALESCE(app_version, ''), COALESCE(git_commit, ''), COALESCE(config_hash, ''), heartbeat_at`
+
+// abandonedMessage is the error message recorded for abandoned runs
+func abandonedMessage(timeout time.Duration) string {
+	return fmt.Sprintf("abandoned: no heartbeat for %s, the process running it probably died", timeout)
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanETLRun scans a row of etlRunColumns
+func scanETLRun(row rowScanner) (*ETLRunInfo, error) {
 	var run ETLRunInfo
-	var completedAt sql.NullTime
+	var completedAt, heartbeatAt sql.NullTime
 	var errorMsg sql.NullString
 
-	err := v.db.QueryRowContext(ctx, query).Scan(
+	err := row.Scan(
 		&run.ID,
 		&run.StartedAt,
 		&completedAt,
@@ -307,18 +782,26 @@ func (v *VerticaDB) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
 		&run.EventsProcessed,
 		&run.CategoriesProcessed,
 		&errorMsg,
+		&run.ExtractDurationMs,
+		&run.TransformDurationMs,
+		&run.LoadDurationMs,
+		&run.APIRequests,
+		&run.BytesDownloaded,
+		&run.AppVersion,
+		&run.GitCommit,
+		&run.ConfigHash,
+		&heartbeatAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // No previous runs
-		}
-		return nil, fmt.Errorf("failed to get last ETL run: %w", err)
+		return nil, err
 	}
 
 	if completedAt.Valid {
 		run.CompletedAt = &completedAt.Time
 	}
+	if heartbeatAt.Valid {
+		run.HeartbeatAt = &heartbeatAt.Time
+	}
 	if errorMsg.Valid {
 		run.ErrorMessage = &errorMsg.String
 	}
@@ -326,15 +809,46 @@ func (v *VerticaDB) GetLastETLRun(ctx context.Context) (*ETLRunInfo, error) {
 	return &run, nil
 }
 
-// ETLRunInfo represents information about an ETL run
-type ETLRunInfo struct {
-	ID                  int64      `json:"id"`
-	StartedAt           time.Time  `json:"started_at"`
-	CompletedAt         *time.Time `json:"completed_at,omitempty"`
-	Status              string     `json:"status"`
-	EventsProcessed     int        `json:"events_processed"`
-	CategoriesProcessed int        `json:"categories_processed"`
-	ErrorMessage        *string    `json:"error_message,omitempty"`
+// Migrator returns a migrator for the Vertica schema migrations
+func (v *VerticaDB) Migrator() (*Migrator, error) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations/vertica")
+	if err != nil {
+		return nil, err
+	}
+	migrator := NewMigrator(v.db, migrations, v.logger)
+	migrator.lock = v.lockMigrations
+	return migrator, nil
+}
+
+// lockMigrations holds an exclusive lock on the schema_migrations_lock table
+// in a transaction of a dedicated connection, so that replicas starting
+// together migrate one after the other. Vertica commits DDL implicitly, so the
+// lock lives apart from the migrations, which run on other connections.
+func (v *VerticaDB) lockMigrations(ctx context.Context) (func(), error) {
+	if _, err := v.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations_lock (id INTEGER)`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations_lock table: %w", err)
+	}
+
+	conn, err := v.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE schema_migrations_lock IN EXCLUSIVE MODE`); err != nil {
+		tx.Rollback()
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock schema_migrations_lock: %w", err)
+	}
+
+	return func() {
+		tx.Rollback()
+		conn.Close()
+	}, nil
 }
 
 // HealthCheck checks if the database is accessible
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nasa-data-hub-etl/pkg/models"
+
+	vertigo "github.com/vertica/vertica-sql-go"
+)
+
+// bulkLoadMinRows is the number of rows from which UpsertEvents loads events
+// with COPY FROM STDIN instead of one MERGE per row
+const bulkLoadMinRows = 100
+
+// Load paths reported by UpsertEvents
+const (
+	loadPathCopy  = "copy"
+	loadPathMerge = "merge"
+)
+
+// COPY format: ASCII unit and record separators delimit fields and records so
+// JSON values need no escaping, SUB marks NULL
+const (
+	copyDelimiter  = '\x1f'
+	copyTerminator = '\x1e'
+	copyNull       = "\x1a"
+	copyOptions    = `DELIMITER E'\x1F' RECORD TERMINATOR E'\x1E' NULL E'\x1A' NO ESCAPE ABORT ON ERROR`
+)
+
+var errCopyValue = errors.New("value cannot be loaded with COPY")
+
+// stagingTable is a session-local table that COPY loads into before the rows
+// are merged into target
+type stagingTable struct {
+	name       string
+	target     string
+	columns    []string
+	definition string
+}
+
+var (
+	eventsStaging = stagingTable{
+		name:    "events_stage",
+		target:  "events",
+		columns: []string{"id", "title", "description", "link", "categories", "sources", "geometry", "closed"},
+		definition: `id VARCHAR(50), title VARCHAR(500), description VARCHAR(10000), link VARCHAR(500),
+			categories VARCHAR(10000), sources VARCHAR(65000), geometry VARCHAR(65000), closed VARCHAR(50)`,
+	}
+	eventGeometriesStaging = stagingTable{
+		name:   "event_geometries_stage",
+		target: "event_geometries",
+		columns: []string{"event_id", "seq", "observed_at", "geometry_type", "longitude", "latitude",
+			"magnitude_value", "magnitude_unit", "magnitude_description", "coordinates",
+			"min_lon", "min_lat", "max_lon", "max_lat"},
+		definition: `event_id VARCHAR(50), seq INTEGER, observed_at TIMESTAMP, geometry_type VARCHAR(20),
+			longitude FLOAT, latitude FLOAT, magnitude_value FLOAT, magnitude_unit VARCHAR(50),
+			magnitude_description VARCHAR(500), coordinates VARCHAR(65000),
+			min_lon FLOAT, min_lat FLOAT, max_lon FLOAT, max_lat FLOAT`,
+	}
+	eventSourcesStaging = stagingTable{
+		name:       "event_sources_stage",
+		target:     "event_sources",
+		columns:    []string{"event_id", "source_id", "url"},
+		definition: `event_id VARCHAR(50), source_id VARCHAR(100), url VARCHAR(2000)`,
+	}
+	eventCategoriesStaging = stagingTable{
+		name:       "event_categories_stage",
+		target:     "event_categories",
+		columns:    []string{"event_id", "category_id"},
+		definition: `event_id VARCHAR(50), category_id VARCHAR(64)`,
+	}
+)
+
+// createStatement creates the staging table, its rows are deleted on commit
+func (t stagingTable) createStatement() string {
+	return fmt.Sprintf(`CREATE LOCAL TEMPORARY TABLE IF NOT EXISTS %s (%s) ON COMMIT DELETE ROWS`, t.name, t.definition)
+}
+
+// copyStatement loads the staging table from STDIN
+func (t stagingTable) copyStatement() string {
+	return fmt.Sprintf(`COPY %s (%s) FROM STDIN %s`, t.name, strings.Join(t.columns, ", "), copyOptions)
+}
+
+// insertStatement appends the staged rows to the target table
+func (t stagingTable) insertStatement() string {
+	columns := strings.Join(t.columns, ", ")
+	return fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, t.target, columns, columns, t.name)
+}
+
+// createStagingTables creates the staging tables on conn. Vertica commits DDL
+// implicitly, so this runs before the load transaction on the same session.
+func createStagingTables(ctx context.Context, conn *sql.Conn) error {
+	for _, table := range []stagingTable{eventsStaging, eventGeometriesStaging, eventSourcesStaging, eventCategoriesStaging} {
+		if _, err := conn.ExecContext(ctx, table.createStatement()); err != nil {
+			return fmt.Errorf("failed to create staging table %s: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// copyBuffer accumulates rows in the COPY format
+type copyBuffer struct {
+	buf  bytes.Buffer
+	rows int
+}
+
+// writeRow appends one record, values must be in staging column order
+func (c *copyBuffer) writeRow(values ...any) error {
+	for i, value := range values {
+		if i > 0 {
+			c.buf.WriteByte(copyDelimiter)
+		}
+		field, err := copyField(value)
+		if err != nil {
+			return err
+		}
+		c.buf.WriteString(field)
+	}
+	c.buf.WriteByte(copyTerminator)
+	c.rows++
+	return nil
+}
+
+// copyField formats a value as a COPY field
+func copyField(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return copyNull, nil
+	case string:
+		if strings.ContainsAny(v, "\x1a\x1e\x1f") {
+			return "", fmt.Errorf("%w: string contains a COPY separator", errCopyValue)
+		}
+		return v, nil
+	case *string:
+		if v == nil {
+			return copyNull, nil
+		}
+		return copyField(*v)
+	case int:
+		return strconv.Itoa(v), nil
+	case *float64:
+		if v == nil {
+			return copyNull, nil
+		}
+		return strconv.FormatFloat(*v, 'g', -1, 64), nil
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999"), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported type %T", errCopyValue, value)
+	}
+}
+
+// copyEvents streams events and their child rows into the staging tables
+// with COPY FROM STDIN and merges them into the target tables
+func copyEvents(ctx context.Context, tx *sql.Tx, events []*models.EventRecord) error {
+	var eventRows, geometryRows, sourceRows, categoryRows copyBuffer
+
+	for _, event := range events {
+		if err := eventRows.writeRow(event.ID, event.Title, event.Description, event.Link,
+			event.Categories, event.Sources, event.Geometry, event.Closed); err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+		for _, g := range event.Geometries {
+			envelope := geometryEnvelope(g)
+			if err := geometryRows.writeRow(event.ID, g.Seq, g.ObservedAt, g.Type, g.Longitude, g.Latitude,
+				g.MagnitudeValue, g.MagnitudeUnit, g.MagnitudeDescription, g.Coordinates,
+				envelope[0], envelope[1], envelope[2], envelope[3]); err != nil {
+				return fmt.Errorf("failed to encode geometry %d of event %s: %w", g.Seq, event.ID, err)
+			}
+		}
+		for _, source := range event.SourceLinks {
+			if err := sourceRows.writeRow(event.ID, source.SourceID, source.URL); err != nil {
+				return fmt.Errorf("failed to encode source %s of event %s: %w", source.SourceID, event.ID, err)
+			}
+		}
+		for _, categoryID := range event.CategoryIDs {
+			if err := categoryRows.writeRow(event.ID, categoryID); err != nil {
+				return fmt.Errorf("failed to encode category %s of event %s: %w", categoryID, event.ID, err)
+			}
+		}
+	}
+
+	staged := []struct {
+		table stagingTable
+		rows  *copyBuffer
+	}{
+		{eventsStaging, &eventRows},
+		{eventGeometriesStaging, &geometryRows},
+		{eventSourcesStaging, &sourceRows},
+		{eventCategoriesStaging, &categoryRows},
+	}
+
+	for _, s := range staged {
+		if s.rows.rows == 0 {
+			continue
+		}
+
+		vctx := vertigo.NewVerticaContext(ctx)
+		if err := vctx.SetCopyInputStream(&s.rows.buf); err != nil {
+			return fmt.Errorf("failed to set COPY input: %w", err)
+		}
+		if _, err := tx.ExecContext(vctx, s.table.copyStatement()); err != nil {
+			return fmt.Errorf("failed to copy into %s: %w", s.table.name, err)
+		}
+	}
+
+	statements := []string{`
+		MERGE INTO events t
+		USING events_stage s
+		ON t.id = s.id
+		WHEN MATCHED THEN UPDATE SET
+			title = s.title,
+			description = s.description,
+			link = s.link,
+			categories = s.categories,
+			sources = s.sources,
+			geometry = s.geometry,
+			closed = s.closed,
+			updated_at = CURRENT_TIMESTAMP
+		WHEN NOT MATCHED THEN INSERT (id, title, description, link, categories, sources, geometry, closed, created_at, updated_at)
+			VALUES (s.id, s.title, s.description, s.link, s.categories, s.sources, s.geometry, s.closed, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`}
+	for _, s := range staged[1:] {
+		statements = append(statements, fmt.Sprintf(`DELETE FROM %s WHERE event_id IN (SELECT id FROM events_stage)`, s.table.target))
+		if s.rows.rows > 0 {
+			statements = append(statements, s.table.insertStatement())
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to merge staged events: %w", err)
+		}
+	}
+
+	return nil
+}
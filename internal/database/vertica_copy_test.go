@@ -0,0 +1,50 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCopyBuffer_WriteRow(t *testing.T) {
+	closed := "2024-07-02T00:00:00Z"
+	value := 12.5
+
+	var rows copyBuffer
+	if err := rows.writeRow("EONET_1", `{"a":"b|c"}`, &closed, (*string)(nil), 3, &value, (*float64)(nil),
+		time.Date(2024, 7, 1, 12, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("writeRow() error = %v", err)
+	}
+	if err := rows.writeRow("EONET_2", "", nil, nil, 0, nil, nil, time.Date(2024, 7, 1, 0, 0, 0, 500000000, time.UTC)); err != nil {
+		t.Fatalf("writeRow() error = %v", err)
+	}
+
+	want := "EONET_1\x1f{\"a\":\"b|c\"}\x1f2024-07-02T00:00:00Z\x1f\x1a\x1f3\x1f12.5\x1f\x1a\x1f2024-07-01 12:30:00\x1e" +
+		"EONET_2\x1f\x1f\x1a\x1f\x1a\x1f0\x1f\x1a\x1f\x1a\x1f2024-07-01 00:00:00.5\x1e"
+	if got := rows.buf.String(); got != want {
+		t.Errorf("writeRow() wrote %q, want %q", got, want)
+	}
+	if rows.rows != 2 {
+		t.Errorf("rows = %d, want 2", rows.rows)
+	}
+}
+
+func TestCopyField_Unsupported(t *testing.T) {
+	for _, value := range []any{"a\x1fb", "a\x1eb", "\x1a", true} {
+		if _, err := copyField(value); !errors.Is(err, errCopyValue) {
+			t.Errorf("copyField(%q) error = %v, want errCopyValue", value, err)
+		}
+	}
+}
+
+func TestStagingTable_Statements(t *testing.T) {
+	if got, want := eventSourcesStaging.copyStatement(),
+		`COPY event_sources_stage (event_id, source_id, url) FROM STDIN `+copyOptions; got != want {
+		t.Errorf("copyStatement() = %q, want %q", got, want)
+	}
+
+	if got, want := eventSourcesStaging.insertStatement(),
+		`INSERT INTO event_sources (event_id, source_id, url) SELECT event_id, source_id, url FROM event_sources_stage`; got != want {
+		t.Errorf("insertStatement() = %q, want %q", got, want)
+	}
+}
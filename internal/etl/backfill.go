@@ -0,0 +1,239 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"nasa-data-hub-etl/internal/api"
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/logger"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Backfill chunk statuses
+const (
+	chunkStatusRunning   = "running"
+	chunkStatusCompleted = "completed"
+	chunkStatusFailed    = "failed"
+)
+
+// DateRange is a half-open interval [Start, End)
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitDateRange splits [from, to) into consecutive chunks aligned to calendar
+// boundaries. chunk is one of "day", "week", "month" or "year". The first and
+// last chunks are clipped to from and to.
+func SplitDateRange(from, to time.Time, chunk string) ([]DateRange, error) {
+	from = truncateToDay(from)
+	to = truncateToDay(to)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("backfill start %s must be before end %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	var next func(time.Time) time.Time
+	switch strings.ToLower(chunk) {
+	case "day":
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "week":
+		// Weeks start on Monday
+		next = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, 7-(int(t.Weekday())+6)%7)
+		}
+	case "month":
+		next = func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		}
+	case "year":
+		next = func(t time.Time) time.Time {
+			return time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+	default:
+		return nil, fmt.Errorf("invalid backfill chunk: %s, supported chunks: day, week, month, year", chunk)
+	}
+
+	var ranges []DateRange
+	for start := from; start.Before(to); {
+		end := next(start)
+		if end.After(to) {
+			end = to
+		}
+		ranges = append(ranges, DateRange{Start: start, End: end})
+		start = end
+	}
+
+	return ranges, nil
+}
+
+// truncateToDay returns midnight UTC of the given time's date
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Backfill loads events between from and to, one chunk at a time. Progress is
+// recorded per chunk so that an interrupted backfill resumes after the last
+// completed chunk when started again with the same range and chunk size.
+// Like a run, a backfill needs the leader lease and fails with
+// ErrRunInProgress while a run is in progress, and runs cannot start until it
+// has finished. The backfill is recorded in etl_runs as a run of its own.
+func (p *Pipeline) Backfill(ctx context.Context, from, to time.Time, chunk string) error {
+	ranges, err := SplitDateRange(from, to, chunk)
+	if err != nil {
+		return err
+	}
+
+	ctx, runID, finish, err := p.beginRun(ctx)
+	if err != nil {
+		return err
+	}
+	defer finish()
+
+	ctx = logger.WithFields(ctx, logrus.Fields{
+		logger.FieldRunID: runID,
+		logger.FieldPhase: runPhase,
+	})
+
+	return p.trackRun(ctx, runID, "etl.backfill", []attribute.KeyValue{
+		attribute.String("etl.backfill.from", ranges[0].Start.Format("2006-01-02")),
+		attribute.String("etl.backfill.to", ranges[len(ranges)-1].End.Format("2006-01-02")),
+		attribute.String("etl.backfill.chunk", chunk),
+	}, func(ctx context.Context, stats *runStats) error {
+		return p.backfill(ctx, ranges, chunk, stats)
+	})
+}
+
+// backfill loads the chunks of ranges that are not completed yet
+func (p *Pipeline) backfill(ctx context.Context, ranges []DateRange, chunk string, stats *runStats) error {
+	done, err := p.completedChunks(ctx, ranges)
+	if err != nil {
+		return err
+	}
+
+	p.log(ctx).WithFields(logrus.Fields{
+		"from":      ranges[0].Start.Format("2006-01-02"),
+		"to":        ranges[len(ranges)-1].End.Format("2006-01-02"),
+		"chunk":     chunk,
+		"chunks":    len(ranges),
+		"completed": len(done),
+	}).Info("Starting backfill")
+
+	// Events reference categories, make sure they are current
+	stats.categories, err = p.processCategories(ctx, &stats.phases)
+	if err != nil {
+		return fmt.Errorf("failed to process categories: %w", err)
+	}
+
+	for i, r := range ranges {
+		if done[r.Start] {
+			continue
+		}
+
+		result, err := p.backfillChunk(ctx, r, &stats.phases)
+		if err != nil {
+			return fmt.Errorf("backfill chunk %s to %s failed: %w", r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), err)
+		}
+		stats.events = stats.events.Add(result)
+
+		p.log(ctx).WithFields(logrus.Fields{
+			"chunk":    fmt.Sprintf("%d/%d", i+1, len(ranges)),
+			"start":    r.Start.Format("2006-01-02"),
+			"inserted": result.Inserted,
+			"updated":  result.Updated,
+		}).Info("Backfill chunk completed")
+	}
+
+	p.log(ctx).WithFields(logrus.Fields{
+		"inserted":  stats.events.Inserted,
+		"updated":   stats.events.Updated,
+		"unchanged": stats.events.Unchanged,
+	}).Info("Backfill completed successfully")
+	return nil
+}
+
+// completedChunks returns the start dates of chunks already completed
+func (p *Pipeline) completedChunks(ctx context.Context, ranges []DateRange) (map[time.Time]bool, error) {
+	chunks, err := p.db.ListBackfillChunks(ctx, ranges[0].Start, ranges[len(ranges)-1].End)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[time.Time]time.Time, len(ranges))
+	for _, r := range ranges {
+		wanted[r.Start] = r.End
+	}
+
+	done := make(map[time.Time]bool)
+	for _, chunk := range chunks {
+		start := truncateToDay(chunk.Start)
+		if end, ok := wanted[start]; ok && end.Equal(truncateToDay(chunk.End)) && chunk.Status == chunkStatusCompleted {
+			done[start] = true
+		}
+	}
+	return done, nil
+}
+
+// backfillChunk loads a single chunk and records its progress. The time spent
+// is added to phases.
+func (p *Pipeline) backfillChunk(ctx context.Context, r DateRange, phases *runPhases) (database.UpsertResult, error) {
+	chunk := &database.BackfillChunk{Start: r.Start, End: r.End, Status: chunkStatusRunning}
+	if err := p.db.SaveBackfillChunk(ctx, chunk); err != nil {
+		return database.UpsertResult{}, err
+	}
+
+	result, err := p.loadChunk(ctx, r, phases)
+	if err != nil {
+		chunk.Status = chunkStatusFailed
+		msg := err.Error()
+		chunk.ErrorMessage = &msg
+
+		// Record the failure even if ctx was cancelled by a shutdown signal
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if saveErr := p.db.SaveBackfillChunk(saveCtx, chunk); saveErr != nil {
+			p.log(ctx).WithError(saveErr).Error("Failed to record backfill chunk failure")
+		}
+		return database.UpsertResult{}, err
+	}
+
+	now := time.Now().UTC()
+	chunk.Status = chunkStatusCompleted
+	chunk.EventsLoaded = result.Total()
+	chunk.CompletedAt = &now
+	if err := p.db.SaveBackfillChunk(ctx, chunk); err != nil {
+		return database.UpsertResult{}, err
+	}
+
+	return result, nil
+}
+
+// loadChunk fetches and loads the events of a single date range
+func (p *Pipeline) loadChunk(ctx context.Context, r DateRange, phases *runPhases) (database.UpsertResult, error) {
+	opts := api.FetchEventsOptions{
+		Start:  r.Start,
+		End:    r.End.AddDate(0, 0, -1), // EONET end dates are inclusive
+		Limit:  p.config.ETL.BatchSize,
+		Status: "all",
+	}
+
+	loader := p.newEventLoader(ctx, phases)
+	stream, err := loader.stream(opts)
+	if err != nil {
+		return database.UpsertResult{}, err
+	}
+
+	if stream.Truncated {
+		p.log(ctx).WithFields(logrus.Fields{
+			"start": r.Start.Format("2006-01-02"),
+			"limit": p.config.ETL.BatchSize,
+		}).Warn("Backfill chunk hit the event limit on a single day, some events may be missing")
+	}
+
+	return loader.result, nil
+}
@@ -0,0 +1,112 @@
+package etl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+)
+
+func TestSplitDateRange(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		chunk   string
+		want    [][2]string
+		wantErr bool
+	}{
+		{
+			name:  "months clipped at both ends",
+			from:  "2024-01-15",
+			to:    "2024-03-10",
+			chunk: "month",
+			want:  [][2]string{{"2024-01-15", "2024-02-01"}, {"2024-02-01", "2024-03-01"}, {"2024-03-01", "2024-03-10"}},
+		},
+		{
+			name:  "weeks start on monday",
+			from:  "2024-01-03", // Wednesday
+			to:    "2024-01-16",
+			chunk: "week",
+			want:  [][2]string{{"2024-01-03", "2024-01-08"}, {"2024-01-08", "2024-01-15"}, {"2024-01-15", "2024-01-16"}},
+		},
+		{
+			name:  "days",
+			from:  "2024-02-28",
+			to:    "2024-03-01",
+			chunk: "day",
+			want:  [][2]string{{"2024-02-28", "2024-02-29"}, {"2024-02-29", "2024-03-01"}},
+		},
+		{
+			name:  "years, case insensitive",
+			from:  "2022-06-01",
+			to:    "2024-01-01",
+			chunk: "Year",
+			want:  [][2]string{{"2022-06-01", "2023-01-01"}, {"2023-01-01", "2024-01-01"}},
+		},
+		{name: "empty range", from: "2024-01-01", to: "2024-01-01", chunk: "month", wantErr: true},
+		{name: "unknown chunk", from: "2024-01-01", to: "2024-02-01", chunk: "fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitDateRange(date(tt.from), date(tt.to), tt.chunk)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SplitDateRange() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitDateRange() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("SplitDateRange() returned %d chunks, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, r := range got {
+				if !r.Start.Equal(date(tt.want[i][0])) || !r.End.Equal(date(tt.want[i][1])) {
+					t.Errorf("chunk %d = [%s, %s), want [%s, %s)", i,
+						r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), tt.want[i][0], tt.want[i][1])
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_BackfillRecordsRun(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, newTestEONETServer(t).URL, store)
+	ctx := context.Background()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := p.Backfill(ctx, from, from.AddDate(0, 2, 0), "month"); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+
+	runs, err := store.ListETLRuns(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListETLRuns() error = %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("ListETLRuns() returned %d runs, want the backfill", len(runs))
+	}
+
+	// The categories and one events request per chunk, each loading both events
+	run := runs[0]
+	if run.Status != "completed" || run.CompletedAt == nil {
+		t.Errorf("backfill run status = %s, completed at %v, want completed", run.Status, run.CompletedAt)
+	}
+	if run.EventsProcessed != 4 || run.CategoriesProcessed != 2 || run.APIRequests != 3 {
+		t.Errorf("backfill run processed %d events, %d categories in %d requests, want 4, 2 and 3",
+			run.EventsProcessed, run.CategoriesProcessed, run.APIRequests)
+	}
+}
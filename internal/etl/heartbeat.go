@@ -0,0 +1,73 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"nasa-data-hub-etl/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// startHeartbeat updates the heartbeat of run every etl.heartbeat_interval
+// until the returned stop function is called
+func (p *Pipeline) startHeartbeat(ctx context.Context, runID int64) (stop func()) {
+	if p.config.ETL.HeartbeatInterval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(p.config.ETL.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.db.HeartbeatETLRun(ctx, runID); err != nil && ctx.Err() == nil {
+					p.log(ctx).WithError(err).Warn("Failed to record ETL run heartbeat")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// RecoverStaleRuns marks runs left 'running' by a process that died as
+// abandoned. A run is stale when its heartbeat is older than
+// etl.stale_run_timeout.
+func (p *Pipeline) RecoverStaleRuns(ctx context.Context) (int64, error) {
+	abandoned, err := p.db.AbandonStaleETLRuns(ctx, p.config.ETL.StaleRunTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover stale runs: %w", err)
+	}
+
+	if abandoned > 0 {
+		p.abandonedRuns.Add(abandoned)
+		metrics.RunsAbandoned.Add(float64(abandoned))
+		p.log(ctx).WithFields(logrus.Fields{
+			"abandoned": abandoned,
+			"timeout":   p.config.ETL.StaleRunTimeout.String(),
+		}).Warn("Marked stale ETL runs as abandoned")
+	}
+
+	return abandoned, nil
+}
+
+// AbandonedRuns returns how many stale runs this process marked as abandoned
+func (p *Pipeline) AbandonedRuns() int64 {
+	return p.abandonedRuns.Load()
+}
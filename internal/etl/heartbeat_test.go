@@ -0,0 +1,54 @@
+package etl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+)
+
+func TestPipeline_Heartbeat(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, "http://unused", store)
+	p.config.ETL.HeartbeatInterval = time.Millisecond
+	ctx := context.Background()
+
+	runID, _ := store.StartETLRun(ctx, database.ETLRunStart{})
+	started, _ := store.GetLastETLRun(ctx)
+
+	stop := p.startHeartbeat(ctx, runID)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	run, _ := store.GetLastETLRun(ctx)
+	if run.HeartbeatAt == nil || !run.HeartbeatAt.After(*started.HeartbeatAt) {
+		t.Errorf("heartbeat = %v, want it after %v", run.HeartbeatAt, started.HeartbeatAt)
+	}
+}
+
+func TestPipeline_RecoverStaleRuns(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, "http://unused", store)
+	p.config.ETL.StaleRunTimeout = time.Millisecond
+	ctx := context.Background()
+
+	// A run whose process died without completing it
+	if _, err := store.StartETLRun(ctx, database.ETLRunStart{}); err != nil {
+		t.Fatalf("StartETLRun() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	abandoned, err := p.RecoverStaleRuns(ctx)
+	if err != nil || abandoned != 1 {
+		t.Fatalf("RecoverStaleRuns() = %d, %v, want 1", abandoned, err)
+	}
+	if p.AbandonedRuns() != 1 {
+		t.Errorf("AbandonedRuns() = %d, want 1", p.AbandonedRuns())
+	}
+
+	run, _ := p.GetLastRunInfo(ctx)
+	if run.Status != "abandoned" {
+		t.Errorf("last run status = %q, want abandoned", run.Status)
+	}
+}
@@ -0,0 +1,185 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pipelineLease is the name of the lease that the running pipeline holds
+const pipelineLease = "etl_pipeline"
+
+// LeaderElector keeps trying to hold a database lease and reports whether
+// this instance is the leader. Instances that do not hold the lease stay on
+// hot standby and take over once it expires.
+type LeaderElector struct {
+	store  database.LeaseStore
+	name   string
+	owner  string
+	ttl    time.Duration
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	term      context.Context // Cancelled when leadership is lost, nil while not leader
+	endTerm   context.CancelFunc
+	renewedAt time.Time
+}
+
+// NewLeaderElector creates an elector for the lease name held by owner for ttl
+func NewLeaderElector(store database.LeaseStore, name, owner string, ttl time.Duration, logger *logrus.Logger) *LeaderElector {
+	return &LeaderElector{
+		store:  store,
+		name:   name,
+		owner:  owner,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// leaseOwner identifies this process, the hostname is the pod name on
+// Kubernetes
+func leaseOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Start tries to acquire the lease once, then renews or retries every third
+// of the ttl in the background. The returned stop function ends the loop and
+// releases the lease so that a standby can take over right away.
+func (e *LeaderElector) Start(ctx context.Context) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	e.tryAcquire(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(e.renewInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				e.release()
+				return
+			case <-ticker.C:
+				e.tryAcquire(ctx)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// tryAcquire acquires or renews the lease and updates the leadership state
+func (e *LeaderElector) tryAcquire(ctx context.Context) {
+	// The database counts the ttl from some time during the call, so the
+	// lease is only known to be ours for the ttl from before it. A call that
+	// hangs must not hold up the step down below.
+	attempt := time.Now()
+	acquireCtx, cancel := context.WithTimeout(ctx, e.renewInterval())
+	acquired, err := e.store.AcquireLease(acquireCtx, e.name, e.owner, e.ttl)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		e.logger.WithError(err).WithField("lease", e.name).Warn("Failed to renew leader lease")
+
+		// The lease is still ours until it expires. Renewals are only tried
+		// every renew interval, so step down one interval before expiry
+		// lest a standby takes the lease while this instance still runs.
+		e.mu.Lock()
+		expiring := time.Since(e.renewedAt) >= e.ttl-e.renewInterval()
+		e.mu.Unlock()
+		if expiring {
+			e.setLeader(false)
+		}
+		return
+	}
+
+	if acquired {
+		e.mu.Lock()
+		e.renewedAt = attempt
+		e.mu.Unlock()
+	}
+	e.setLeader(acquired)
+}
+
+// renewInterval is the time between attempts to acquire or renew the lease
+func (e *LeaderElector) renewInterval() time.Duration {
+	return e.ttl / 3
+}
+
+// release gives up the lease if this instance holds it
+func (e *LeaderElector) release() {
+	if !e.IsLeader() {
+		return
+	}
+	e.setLeader(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.store.ReleaseLease(ctx, e.name, e.owner); err != nil {
+		e.logger.WithError(err).WithField("lease", e.name).Warn("Failed to release leader lease")
+	}
+}
+
+// setLeader starts or ends a leadership term
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fields := logrus.Fields{"lease": e.name, "owner": e.owner}
+	switch {
+	case leader && e.term == nil:
+		e.term, e.endTerm = context.WithCancel(context.Background())
+		metrics.Leader.Set(1)
+		e.logger.WithFields(fields).Info("Acquired leader lease, this instance runs the pipeline")
+	case !leader && e.term != nil:
+		e.endTerm()
+		e.term, e.endTerm = nil, nil
+		metrics.Leader.Set(0)
+		e.logger.WithFields(fields).Warn("Lost leader lease, this instance is on standby")
+	}
+}
+
+// IsLeader reports whether this instance holds the lease
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.term != nil
+}
+
+// TermContext returns a context derived from ctx that is cancelled when
+// leadership is lost. ok is false if this instance is not the leader.
+func (e *LeaderElector) TermContext(ctx context.Context) (termCtx context.Context, cancel context.CancelFunc, ok bool) {
+	e.mu.Lock()
+	term := e.term
+	e.mu.Unlock()
+	if term == nil {
+		return nil, nil, false
+	}
+
+	ctx, cancelCtx := context.WithCancel(ctx)
+	stop := context.AfterFunc(term, cancelCtx)
+	return ctx, func() {
+		stop()
+		cancelCtx()
+	}, true
+}
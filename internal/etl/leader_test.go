@@ -0,0 +1,187 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLeaderElector_Failover(t *testing.T) {
+	store := database.NewMemoryStore()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	first := NewLeaderElector(store, pipelineLease, "first", 30*time.Millisecond, logger)
+	second := NewLeaderElector(store, pipelineLease, "second", 30*time.Millisecond, logger)
+
+	stopFirst := first.Start(ctx)
+	stopSecond := second.Start(ctx)
+	defer stopSecond()
+
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("IsLeader() = %v, %v, want only the first instance to lead", first.IsLeader(), second.IsLeader())
+	}
+
+	// The renewals keep the lease with the first instance
+	time.Sleep(60 * time.Millisecond)
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("after renewals IsLeader() = %v, %v", first.IsLeader(), second.IsLeader())
+	}
+
+	if _, _, ok := second.TermContext(ctx); ok {
+		t.Error("TermContext() on standby ok = true, want false")
+	}
+
+	// Stopping releases the lease and the standby takes over
+	stopFirst()
+	deadline := time.Now().Add(time.Second)
+	for !second.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if first.IsLeader() || !second.IsLeader() {
+		t.Errorf("after stop IsLeader() = %v, %v, want the second instance to lead", first.IsLeader(), second.IsLeader())
+	}
+}
+
+// failingLeaseStore grants the first lease and fails every renewal
+type failingLeaseStore struct {
+	database.LeaseStore
+	calls int
+}
+
+func (s *failingLeaseStore) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	s.calls++
+	if s.calls > 1 {
+		return false, errors.New("database unavailable")
+	}
+	return s.LeaseStore.AcquireLease(ctx, name, owner, ttl)
+}
+
+func TestLeaderElector_StepsDownBeforeLeaseExpires(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ttl := 300 * time.Millisecond
+
+	acquired := time.Now()
+	elector := NewLeaderElector(&failingLeaseStore{LeaseStore: database.NewMemoryStore()}, pipelineLease, "owner", ttl, logger)
+	stop := elector.Start(context.Background())
+	defer stop()
+
+	if !elector.IsLeader() {
+		t.Fatal("IsLeader() = false after the first acquire")
+	}
+	for elector.IsLeader() && time.Since(acquired) < 2*ttl {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A standby may take the lease once it expired, this instance must have
+	// stopped running the pipeline by then
+	if elapsed := time.Since(acquired); elector.IsLeader() || elapsed >= ttl {
+		t.Errorf("stepped down %v after acquiring a %v lease, want before it expires", elapsed, ttl)
+	}
+}
+
+func TestLeaderElector_RunCancelledOnLostLease(t *testing.T) {
+	store := database.NewMemoryStore()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	elector := NewLeaderElector(store, pipelineLease, "owner", time.Minute, logger)
+	elector.tryAcquire(context.Background())
+
+	ctx, cancel, ok := elector.TermContext(context.Background())
+	if !ok {
+		t.Fatal("TermContext() ok = false, want true")
+	}
+	defer cancel()
+
+	elector.setLeader(false)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("term context not cancelled after losing the lease")
+	}
+}
+
+func TestPipeline_RunSkippedOnStandby(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, "http://unused", store)
+	p.leader = NewLeaderElector(store, pipelineLease, "standby", time.Minute, p.logger)
+
+	// Another instance holds the lease
+	if ok, _ := store.AcquireLease(context.Background(), pipelineLease, "leader", time.Minute); !ok {
+		t.Fatal("AcquireLease() = false, want true")
+	}
+
+	stop := p.StartLeaderElection(context.Background())
+	defer stop()
+
+	if err := p.Run(context.Background()); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("Run() on standby error = %v, want ErrNotLeader", err)
+	}
+	if run, _ := store.GetLastETLRun(context.Background()); run != nil || p.IsLeader() {
+		t.Errorf("standby started run %+v, IsLeader() = %v", run, p.IsLeader())
+	}
+}
+
+func TestPipeline_BackfillClaimsThePipeline(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("standby", func(t *testing.T) {
+		store := database.NewMemoryStore()
+		p := newTestPipeline(t, "http://unused", store)
+		p.leader = NewLeaderElector(store, pipelineLease, "standby", time.Minute, p.logger)
+		if ok, _ := store.AcquireLease(context.Background(), pipelineLease, "leader", time.Minute); !ok {
+			t.Fatal("AcquireLease() = false, want true")
+		}
+
+		stop := p.StartLeaderElection(context.Background())
+		defer stop()
+
+		if err := p.Backfill(context.Background(), from, from.AddDate(0, 1, 0), "month"); !errors.Is(err, ErrNotLeader) {
+			t.Errorf("Backfill() on standby error = %v, want ErrNotLeader", err)
+		}
+	})
+
+	t.Run("run in progress", func(t *testing.T) {
+		p := newTestPipeline(t, "http://unused", database.NewMemoryStore())
+		p.running.Store(true)
+
+		if err := p.Backfill(context.Background(), from, from.AddDate(0, 1, 0), "month"); !errors.Is(err, ErrRunInProgress) {
+			t.Errorf("Backfill() during a run error = %v, want ErrRunInProgress", err)
+		}
+	})
+
+	t.Run("trigger during backfill", func(t *testing.T) {
+		var p *Pipeline
+		var once sync.Once
+		var triggerErr error
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() { _, triggerErr = p.Trigger(context.Background(), RunOptions{}) })
+			fmt.Fprint(w, `{"categories":[],"events":[]}`)
+		}))
+		defer server.Close()
+
+		p = newTestPipeline(t, server.URL, database.NewMemoryStore())
+		if err := p.Backfill(context.Background(), from, from.AddDate(0, 1, 0), "month"); err != nil {
+			t.Fatalf("Backfill() error = %v", err)
+		}
+		if !errors.Is(triggerErr, ErrRunInProgress) {
+			t.Errorf("Trigger() during backfill error = %v, want ErrRunInProgress", triggerErr)
+		}
+		if p.IsRunning() {
+			t.Error("IsRunning() = true after the backfill finished")
+		}
+	})
+}
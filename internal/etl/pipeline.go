@@ -3,42 +3,79 @@ package etl
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"nasa-data-hub-etl/internal/api"
 	"nasa-data-hub-etl/internal/config"
 	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/metrics"
+	"nasa-data-hub-etl/internal/tracing"
+	"nasa-data-hub-etl/internal/version"
 	"nasa-data-hub-etl/pkg/models"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// eventsWatermarkSource identifies the EONET events feed in etl_watermarks
+const eventsWatermarkSource = "eonet_events"
+
 // Pipeline represents the ETL pipeline
 type Pipeline struct {
 	config      *config.Config
 	eonetClient *api.EONETClient
-	db          *database.VerticaDB
+	db          database.Store
 	logger      *logrus.Logger
+	leader      *LeaderElector // nil when leader election is disabled
+
+	running       atomic.Bool
+	abandonedRuns atomic.Int64
 }
 
-// NewPipeline creates a new ETL pipeline
+// NewPipeline creates a new ETL pipeline backed by the configured database
 func NewPipeline(cfg *config.Config, logger *logrus.Logger) (*Pipeline, error) {
-	// Create NASA EONET API client
-	eonetClient := api.NewEONETClient(&cfg.NASA, logger)
-
-	// Create VerticaDB connection
-	db, err := database.NewVerticaDB(&cfg.Database, logger)
+	// Connect to the database selected by database.driver
+	db, err := database.Open(&cfg.Database, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database connection: %w", err)
 	}
 
-	return &Pipeline{
+	return NewPipelineWithStore(cfg, db, logger), nil
+}
+
+// NewPipelineWithStore creates a new ETL pipeline that writes to store
+func NewPipelineWithStore(cfg *config.Config, store database.Store, logger *logrus.Logger) *Pipeline {
+	// Create NASA EONET API client
+	eonetClient := api.NewEONETClient(&cfg.NASA, logger)
+	eonetClient.SetRetryPolicy(api.RetryPolicy{
+		MaxRetries: cfg.ETL.RetryAttempts,
+		BaseDelay:  cfg.ETL.RetryDelay,
+		MaxDelay:   cfg.ETL.RetryMaxDelay,
+	})
+
+	p := &Pipeline{
 		config:      cfg,
 		eonetClient: eonetClient,
-		db:          db,
+		db:          store,
 		logger:      logger,
-	}, nil
+	}
+	if cfg.ETL.LeaderElection {
+		p.leader = NewLeaderElector(store, pipelineLease, leaseOwner(), cfg.ETL.LeaseTTL, logger)
+	} else {
+		metrics.Leader.Set(1)
+	}
+
+	return p
+}
+
+// log returns the logger with the correlation fields carried by ctx
+func (p *Pipeline) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, p.logger)
 }
 
 // InitializeDatabase initializes the database structure
@@ -46,70 +83,351 @@ func (p *Pipeline) InitializeDatabase(ctx context.Context, mode database.InitMod
 	return p.db.InitializeDatabase(ctx, mode)
 }
 
-// Run starts the ETL pipeline
+// phase is a stage of an ETL run whose duration is recorded with the run
+type phase int
+
+const (
+	phaseExtract phase = iota
+	phaseTransform
+	phaseLoad
+)
+
+// String returns the name of ph in the phase log field
+func (ph phase) String() string {
+	return [...]string{"extract", "transform", "load"}[ph]
+}
+
+// runPhase is the phase log field of run level entries outside extract,
+// transform and load
+const runPhase = "run"
+
+// withPhase returns a copy of ctx whose log entries carry ph
+func withPhase(ctx context.Context, ph phase) context.Context {
+	return logger.WithField(ctx, logger.FieldPhase, ph.String())
+}
+
+// runPhases accumulates the time spent in each phase of a run
+type runPhases [3]time.Duration
+
+// add adds the time elapsed since start to ph. It is a no-op on a nil
+// receiver, for callers that do not record a run.
+func (r *runPhases) add(ph phase, start time.Time) {
+	if r != nil {
+		r[ph] += time.Since(start)
+	}
+}
+
+// StartLeaderElection starts competing for the pipeline lease, see
+// LeaderElector.Start. It is a no-op when leader election is disabled.
+func (p *Pipeline) StartLeaderElection(ctx context.Context) (stop func()) {
+	if p.leader == nil {
+		return func() {}
+	}
+	return p.leader.Start(ctx)
+}
+
+// IsLeader reports whether this instance may run the pipeline, which is
+// always the case when leader election is disabled
+func (p *Pipeline) IsLeader() bool {
+	return p.leader == nil || p.leader.IsLeader()
+}
+
+// ErrRunInProgress is returned when a run is requested while another run of
+// the pipeline is still in progress
+var ErrRunInProgress = errors.New("an ETL run is already in progress")
+
+// ErrNotLeader is returned by runs and backfills on an instance that does not
+// hold the leader lease
+var ErrNotLeader = errors.New("this instance is on standby, the leader runs the pipeline")
+
+// MaxRunDays bounds RunOptions.Days. Longer ranges are loaded in chunks by
+// the backfill command.
+const MaxRunDays = 365
+
+// RunOptions overrides the extraction of a single run. Runs with overrides
+// load a partial view of the feed, so they do not advance the watermark.
+type RunOptions struct {
+	Days     int    `json:"days,omitempty"`     // Read the last Days days instead of the window since the watermark
+	Status   string `json:"status,omitempty"`   // EONET event status: open, closed or all (default)
+	Category string `json:"category,omitempty"` // Only events of this category
+}
+
+// IsZero reports whether no override is set
+func (o RunOptions) IsZero() bool {
+	return o == RunOptions{}
+}
+
+// Validate checks the overrides
+func (o RunOptions) Validate() error {
+	if o.Days < 0 {
+		return fmt.Errorf("days must be positive, got %d", o.Days)
+	}
+	if o.Days > MaxRunDays {
+		return fmt.Errorf("days must be at most %d, use the backfill command for longer ranges, got %d", MaxRunDays, o.Days)
+	}
+
+	switch o.Status {
+	case "", "open", "closed", "all":
+	default:
+		return fmt.Errorf("status must be open, closed or all, got %q", o.Status)
+	}
+
+	return nil
+}
+
+// Run starts the ETL pipeline. With leader election enabled it only runs on
+// the instance holding the lease and is cancelled if the lease is lost.
 func (p *Pipeline) Run(ctx context.Context) error {
-	p.logger.Info("Starting ETL pipeline")
+	return p.RunWithOptions(ctx, RunOptions{})
+}
 
-	// Start ETL run tracking
-	runID, err := p.db.StartETLRun(ctx)
+// RunWithOptions runs the pipeline once with overrides, see Run. It returns
+// ErrRunInProgress if another run is still in progress and ErrNotLeader if
+// another instance holds the leader lease.
+func (p *Pipeline) RunWithOptions(ctx context.Context, opts RunOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	runCtx, runID, done, err := p.beginRun(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to start ETL run tracking: %w", err)
+		return err
 	}
+	defer done()
 
-	var eventsProcessed, categoriesProcessed int
-	var finalError error
+	return p.executeRun(runCtx, runID, opts)
+}
 
-	defer func() {
-		status := "completed"
-		var errorMsg *string
-		if finalError != nil {
-			status = "failed"
-			msg := finalError.Error()
-			errorMsg = &msg
-		}
+// Trigger starts a run with overrides in the background and returns its id.
+// The run continues after Trigger returns and is cancelled with ctx.
+func (p *Pipeline) Trigger(ctx context.Context, opts RunOptions) (int64, error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
 
-		if err := p.db.CompleteETLRun(ctx, runID, status, eventsProcessed, categoriesProcessed, errorMsg); err != nil {
-			p.logger.WithError(err).Error("Failed to complete ETL run tracking")
+	ctx, runID, done, err := p.beginRun(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	go func() {
+		defer done()
+
+		if err := p.executeRun(ctx, runID, opts); err != nil {
+			p.log(ctx).WithError(err).WithField(logger.FieldRunID, runID).Error("Triggered ETL run failed")
 		}
 	}()
 
-	// Process categories first
-	if err := p.processCategories(ctx); err != nil {
-		finalError = fmt.Errorf("failed to process categories: %w", err)
-		return finalError
+	return runID, nil
+}
+
+// IsRunning reports whether a run of this pipeline is in progress
+func (p *Pipeline) IsRunning() bool {
+	return p.running.Load()
+}
+
+// beginRun claims the pipeline for a run and records its start. The returned
+// context is cancelled when leadership is lost, done must be called when
+// the run has finished.
+func (p *Pipeline) beginRun(ctx context.Context) (runCtx context.Context, runID int64, done func(), err error) {
+	ctx, release, err := p.claim(ctx)
+	if err != nil {
+		return nil, 0, nil, err
 	}
-	categoriesProcessed = 1 // We process all categories in one batch
 
-	// Process events
-	eventsProcessed, err = p.processEvents(ctx)
+	// Start ETL run tracking
+	build := version.Get()
+	runID, err = p.db.StartETLRun(ctx, database.ETLRunStart{
+		AppVersion: build.Version,
+		GitCommit:  build.GitCommit,
+		ConfigHash: p.config.Hash(),
+	})
 	if err != nil {
-		finalError = fmt.Errorf("failed to process events: %w", err)
-		return finalError
+		release()
+		return nil, 0, nil, fmt.Errorf("failed to start ETL run tracking: %w", err)
+	}
+
+	return ctx, runID, release, nil
+}
+
+// claim makes sure this instance holds the leader lease and that no other
+// run is in progress. The returned context is cancelled when leadership is
+// lost, release must be called when the work has finished.
+func (p *Pipeline) claim(ctx context.Context) (claimCtx context.Context, release func(), err error) {
+	var cancel context.CancelFunc
+	if p.leader != nil {
+		var leader bool
+		if ctx, cancel, leader = p.leader.TermContext(ctx); !leader {
+			return nil, nil, ErrNotLeader
+		}
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
 	}
 
-	p.logger.WithFields(logrus.Fields{
-		"events_processed":     eventsProcessed,
-		"categories_processed": categoriesProcessed,
-	}).Info("ETL pipeline completed successfully")
+	if !p.running.CompareAndSwap(false, true) {
+		cancel()
+		return nil, nil, ErrRunInProgress
+	}
 
-	return nil
+	return ctx, func() {
+		cancel()
+		p.running.Store(false)
+	}, nil
+}
+
+// executeRun performs the run recorded as runID
+func (p *Pipeline) executeRun(ctx context.Context, runID int64, opts RunOptions) error {
+	// Every log entry of the run, including those of the API client and the
+	// store, carries the run id
+	ctx = logger.WithFields(ctx, logrus.Fields{
+		logger.FieldRunID: runID,
+		logger.FieldPhase: runPhase,
+	})
+	p.log(ctx).WithField("options", opts).Info("Starting ETL pipeline")
+
+	return p.trackRun(ctx, runID, "etl.run", []attribute.KeyValue{
+		attribute.Int("etl.override.days", opts.Days),
+		attribute.String("etl.override.status", opts.Status),
+		attribute.String("etl.override.category", opts.Category),
+	}, func(ctx context.Context, stats *runStats) error {
+		var err error
+
+		// Process categories first
+		stats.categories, err = p.processCategories(ctx, &stats.phases)
+		if err != nil {
+			return fmt.Errorf("failed to process categories: %w", err)
+		}
+
+		// Process events
+		stats.events, err = p.processEvents(ctx, opts, &stats.phases)
+		if err != nil {
+			return fmt.Errorf("failed to process events: %w", err)
+		}
+		p.updateOpenEvents(ctx)
+
+		p.log(ctx).WithFields(logrus.Fields{
+			"events_processed":     stats.events.Total(),
+			"events_inserted":      stats.events.Inserted,
+			"events_updated":       stats.events.Updated,
+			"events_unchanged":     stats.events.Unchanged,
+			"categories_processed": stats.categories.Total(),
+			"categories_inserted":  stats.categories.Inserted,
+			"categories_updated":   stats.categories.Updated,
+			"categories_unchanged": stats.categories.Unchanged,
+			"extract_duration":     stats.phases[phaseExtract],
+			"transform_duration":   stats.phases[phaseTransform],
+			"load_duration":        stats.phases[phaseLoad],
+			"api_requests":         stats.requests.Requests(),
+			"bytes_downloaded":     stats.requests.Bytes(),
+		}).Info("ETL pipeline completed successfully")
+
+		return nil
+	})
+}
+
+// runStats collects what a run records in etl_runs
+type runStats struct {
+	phases     runPhases
+	requests   api.RequestStats
+	events     database.UpsertResult
+	categories database.UpsertResult
+}
+
+// trackRun performs work as the run recorded as runID and records its
+// outcome when work returns. The run keeps its heartbeat fresh, is traced as
+// spanName and counts the API traffic of ctx in stats.
+func (p *Pipeline) trackRun(ctx context.Context, runID int64, spanName string, attrs []attribute.KeyValue, work func(ctx context.Context, stats *runStats) error) (finalError error) {
+	// Keep the run's heartbeat fresh so that it is not taken for abandoned.
+	// Deferred after CompleteETLRun so that it stops first.
+	stopHeartbeat := p.startHeartbeat(ctx, runID)
+
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, spanName, append([]attribute.KeyValue{attribute.Int64("etl.run_id", runID)}, attrs...)...)
+
+	// Count the API traffic of this run
+	var stats runStats
+	ctx = api.WithRequestStats(ctx, &stats.requests)
+
+	defer func() {
+		result := database.ETLRunResult{
+			Status:              "completed",
+			EventsProcessed:     stats.events.Total(),
+			CategoriesProcessed: stats.categories.Total(),
+			ExtractDurationMs:   stats.phases[phaseExtract].Milliseconds(),
+			TransformDurationMs: stats.phases[phaseTransform].Milliseconds(),
+			LoadDurationMs:      stats.phases[phaseLoad].Milliseconds(),
+			APIRequests:         stats.requests.Requests(),
+			BytesDownloaded:     stats.requests.Bytes(),
+		}
+		if finalError != nil {
+			result.Status = "failed"
+			if ctx.Err() != nil {
+				result.Status = "cancelled"
+			}
+			msg := finalError.Error()
+			result.ErrorMessage = &msg
+		}
+
+		// Record the outcome even if ctx was cancelled by a shutdown signal,
+		// otherwise the run would stay 'running' forever
+		completeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+
+		if err := p.db.CompleteETLRun(completeCtx, runID, result); err != nil {
+			p.log(ctx).WithError(err).Error("Failed to complete ETL run tracking")
+		}
+
+		span.SetAttributes(
+			attribute.String("etl.run.status", result.Status),
+			attribute.Int("etl.events", result.EventsProcessed),
+			attribute.Int("etl.categories", result.CategoriesProcessed),
+			attribute.Int64("etl.api_requests", result.APIRequests),
+		)
+		tracing.End(span, finalError)
+
+		metrics.RunsTotal.WithLabelValues(result.Status).Inc()
+		metrics.RunDuration.Observe(time.Since(started).Seconds())
+		if finalError == nil {
+			metrics.LastSuccess.SetToCurrentTime()
+		}
+	}()
+	defer stopHeartbeat()
+
+	return work(ctx, &stats)
 }
 
 // processCategories fetches and processes categories
-func (p *Pipeline) processCategories(ctx context.Context) error {
-	p.logger.Info("Processing categories")
+func (p *Pipeline) processCategories(ctx context.Context, phases *runPhases) (result database.UpsertResult, err error) {
+	ctx, span := tracing.Start(ctx, "etl.process_categories")
+	defer func() {
+		span.SetAttributes(result.Attributes()...)
+		tracing.End(span, err)
+	}()
+
+	ctx = withPhase(ctx, phaseExtract)
+	p.log(ctx).Info("Processing categories")
 
 	// Fetch categories from NASA EONET API
+	start := time.Now()
 	categories, err := p.eonetClient.FetchCategories(ctx)
+	phases.add(phaseExtract, start)
 	if err != nil {
-		return fmt.Errorf("failed to fetch categories: %w", err)
+		return database.UpsertResult{}, fmt.Errorf("failed to fetch categories: %w", err)
 	}
 
 	// Transform categories to database records
+	ctx = withPhase(ctx, phaseTransform)
+	start = time.Now()
 	categoryRecords := make([]*models.CategoryRecord, 0, len(categories))
 	for _, category := range categories {
+		id := category.GetID()
+		if id == "" {
+			p.log(ctx).WithField("title", category.Title).Warn("Category without id, skipping")
+			continue
+		}
+
 		record := &models.CategoryRecord{
-			ID:          category.GetIDAsInt(),
+			ID:          id,
 			Title:       category.Title,
 			Link:        category.Link,
 			Description: category.Description,
@@ -119,58 +437,253 @@ func (p *Pipeline) processCategories(ctx context.Context) error {
 		}
 		categoryRecords = append(categoryRecords, record)
 	}
-
-	// Batch insert categories
-	if err := p.db.BatchInsertCategories(ctx, categoryRecords); err != nil {
-		return fmt.Errorf("failed to insert categories: %w", err)
+	phases.add(phaseTransform, start)
+
+	// Upsert categories
+	ctx = withPhase(ctx, phaseLoad)
+	start = time.Now()
+	result, err = p.db.UpsertCategories(ctx, categoryRecords)
+	phases.add(phaseLoad, start)
+	metrics.DBBatchLatency.WithLabelValues(metrics.OpUpsertCategories).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return database.UpsertResult{}, fmt.Errorf("failed to upsert categories: %w", err)
 	}
 
-	p.logger.WithField("count", len(categoryRecords)).Info("Successfully processed categories")
-	return nil
+	p.log(ctx).WithField("count", len(categoryRecords)).Info("Successfully processed categories")
+	return result, nil
 }
 
 // processEvents fetches and processes events
-func (p *Pipeline) processEvents(ctx context.Context) (int, error) {
-	p.logger.Info("Processing events")
+func (p *Pipeline) processEvents(ctx context.Context, overrides RunOptions, phases *runPhases) (result database.UpsertResult, err error) {
+	ctx, span := tracing.Start(ctx, "etl.process_events")
+	defer func() {
+		span.SetAttributes(result.Attributes()...)
+		tracing.End(span, err)
+	}()
+
+	ctx = withPhase(ctx, phaseExtract)
+	p.log(ctx).Info("Processing events")
+
+	watermark, err := p.db.GetWatermark(ctx, eventsWatermarkSource)
+	if err != nil {
+		return database.UpsertResult{}, fmt.Errorf("failed to get watermark: %w", err)
+	}
 
-	// Fetch events from NASA EONET API
+	// Fetch events from NASA EONET API, starting shortly before the watermark
+	// so that late updates to recent events are picked up again
 	opts := api.FetchEventsOptions{
-		Days:   30, // Last 30 days
-		Limit:  p.config.ETL.BatchSize,
-		Status: "all",
+		Start:      p.windowStart(watermark, time.Now()),
+		Limit:      p.config.ETL.BatchSize,
+		Status:     "all",
+		CategoryID: overrides.Category,
+	}
+	if overrides.Days > 0 {
+		opts.Start, opts.Days = time.Time{}, overrides.Days
+	}
+	if overrides.Status != "" {
+		opts.Status = overrides.Status
+	}
+
+	p.log(ctx).WithFields(logrus.Fields{
+		"watermark": watermark,
+		"start":     opts.Start.Format(time.RFC3339),
+		"days":      opts.Days,
+	}).Info("Fetching events since watermark")
+	if !opts.Start.IsZero() {
+		span.SetAttributes(attribute.String("etl.window_start", opts.Start.UTC().Format(time.RFC3339)))
+	}
+
+	loader := p.newEventLoader(ctx, phases)
+	stream, err := loader.stream(opts)
+	if err != nil {
+		return database.UpsertResult{}, err
+	}
+
+	ctx = withPhase(ctx, phaseLoad)
+	if !overrides.IsZero() {
+		p.log(ctx).Info("Run has overrides, not advancing watermark")
+	} else if err := p.advanceWatermark(ctx, watermark, loader.latest, stream.Truncated); err != nil {
+		return database.UpsertResult{}, err
+	}
+
+	p.log(ctx).WithField("count", loader.result.Total()).Info("Successfully processed events")
+	return loader.result, nil
+}
+
+// eventLoader loads streamed events in batches of etl.batch_size, so that
+// only one batch is held in memory however many events are fetched
+type eventLoader struct {
+	p      *Pipeline
+	ctx    context.Context
+	phases *runPhases // May be nil
+	batch  []models.Event
+
+	result database.UpsertResult
+	latest time.Time // Latest activity among the loaded events
+	busy   time.Duration
+}
+
+// newEventLoader returns a loader that adds the time it spends to phases,
+// which may be nil
+func (p *Pipeline) newEventLoader(ctx context.Context, phases *runPhases) *eventLoader {
+	return &eventLoader{p: p, ctx: ctx, phases: phases}
+}
+
+// stream fetches the events of opts and loads them as they arrive
+func (l *eventLoader) stream(opts api.FetchEventsOptions) (api.StreamResult, error) {
+	start := time.Now()
+	stream, err := l.p.eonetClient.StreamEvents(l.ctx, opts, l.add)
+	if err == nil {
+		err = l.flush()
+	}
+
+	// Extraction is the time spent streaming minus the time spent loading
+	l.phases.add(phaseExtract, start.Add(l.busy))
+	if err != nil {
+		return stream, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	return stream, nil
+}
+
+// add queues an event and loads the batch once it is full
+func (l *eventLoader) add(event models.Event) error {
+	if activity := event.LatestActivity(); activity.After(l.latest) {
+		l.latest = activity
+	}
+
+	l.batch = append(l.batch, event)
+	if len(l.batch) < l.p.config.ETL.BatchSize {
+		return nil
+	}
+	return l.flush()
+}
+
+// flush loads the queued events
+func (l *eventLoader) flush() error {
+	if len(l.batch) == 0 {
+		return nil
 	}
 
-	events, err := p.eonetClient.FetchEvents(ctx, opts)
+	start := time.Now()
+	defer func() { l.busy += time.Since(start) }()
+
+	result, err := l.p.loadEvents(l.ctx, l.batch, l.phases)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch events: %w", err)
+		return err
 	}
+	l.result = l.result.Add(result)
+	l.batch = l.batch[:0]
+	return nil
+}
+
+// loadEvents transforms fetched events to database records and upserts them.
+// The time spent is added to phases, which may be nil.
+func (p *Pipeline) loadEvents(ctx context.Context, events []models.Event, phases *runPhases) (database.UpsertResult, error) {
+	metrics.EventsFetched.Add(float64(len(events)))
 
 	// Transform events to database records
-	eventRecords := make([]*models.EventRecord, 0, len(events.Events))
-	for _, event := range events.Events {
+	ctx = withPhase(ctx, phaseTransform)
+	start := time.Now()
+	_, span := tracing.Start(ctx, "etl.transform_events", attribute.Int("etl.records", len(events)))
+	eventRecords := make([]*models.EventRecord, 0, len(events))
+	for _, event := range events {
 		record, err := p.transformEvent(event)
 		if err != nil {
-			p.logger.WithError(err).WithField("event_id", event.ID).Warn("Failed to transform event, skipping")
+			p.log(ctx).WithError(err).WithField(logger.FieldEventID, event.ID).Warn("Failed to transform event, skipping")
+			metrics.EventsSkipped.WithLabelValues(metrics.SkipTransform).Inc()
 			continue
 		}
 		eventRecords = append(eventRecords, record)
 	}
+	phases.add(phaseTransform, start)
+	metrics.EventsTransformed.Add(float64(len(eventRecords)))
+	p.log(ctx).WithFields(logrus.Fields{
+		"transformed": len(eventRecords),
+		"skipped":     len(events) - len(eventRecords),
+	}).Debug("Transformed events")
+	span.SetAttributes(
+		attribute.Int("etl.records.transformed", len(eventRecords)),
+		attribute.Int("etl.records.skipped", len(events)-len(eventRecords)),
+	)
+	span.End()
+
+	// Upsert events
+	ctx = withPhase(ctx, phaseLoad)
+	start = time.Now()
+	result, err := p.db.UpsertEvents(ctx, eventRecords)
+	phases.add(phaseLoad, start)
+	metrics.DBBatchLatency.WithLabelValues(metrics.OpUpsertEvents).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return database.UpsertResult{}, fmt.Errorf("failed to upsert events: %w", err)
+	}
+
+	metrics.EventsLoaded.WithLabelValues("inserted").Add(float64(result.Inserted))
+	metrics.EventsLoaded.WithLabelValues("updated").Add(float64(result.Updated))
+	metrics.EventsLoaded.WithLabelValues("unchanged").Add(float64(result.Unchanged))
+	return result, nil
+}
+
+// updateOpenEvents sets the open events gauge from the store. A failure only
+// leaves the gauge stale, so it does not fail the run.
+func (p *Pipeline) updateOpenEvents(ctx context.Context) {
+	open, err := p.db.CountEvents(ctx, database.EventFilter{Status: database.EventStatusOpen})
+	if err != nil {
+		p.log(ctx).WithError(err).Warn("Failed to count open events")
+		return
+	}
+	metrics.OpenEvents.Set(float64(open))
+}
+
+// windowStart returns the start of the extraction window: the watermark minus
+// the configured overlap, or the initial lookback if there is no watermark yet
+func (p *Pipeline) windowStart(watermark *time.Time, now time.Time) time.Time {
+	if watermark == nil {
+		return now.Add(-p.config.ETL.InitialLookback)
+	}
+	return watermark.Add(-p.config.ETL.Overlap)
+}
+
+// advanceWatermark moves the watermark to latest, the latest activity among
+// the loaded events. It never moves backwards.
+func (p *Pipeline) advanceWatermark(ctx context.Context, current *time.Time, latest time.Time, truncated bool) error {
+	// Events were cut off inside the window, keep the watermark so that the
+	// next run reads the same window again
+	if truncated {
+		p.log(ctx).WithField("limit", p.config.ETL.BatchSize).Warn("Event result hit the limit, not advancing watermark")
+		return nil
+	}
+
+	if latest.IsZero() || (current != nil && !latest.After(*current)) {
+		return nil
+	}
+
+	if err := p.db.SetWatermark(ctx, eventsWatermarkSource, latest); err != nil {
+		return fmt.Errorf("failed to advance watermark: %w", err)
+	}
+
+	p.log(ctx).WithField("watermark", latest.Format(time.RFC3339)).Info("Advanced events watermark")
+	return nil
+}
 
-	// Batch insert events
-	if err := p.db.BatchInsertEvents(ctx, eventRecords); err != nil {
-		return 0, fmt.Errorf("failed to insert events: %w", err)
+// ResetWatermark forgets the events watermark so that the next run starts
+// from the initial lookback window again
+func (p *Pipeline) ResetWatermark(ctx context.Context) error {
+	if err := p.db.ResetWatermark(ctx, eventsWatermarkSource); err != nil {
+		return err
 	}
 
-	p.logger.WithField("count", len(eventRecords)).Info("Successfully processed events")
-	return len(eventRecords), nil
+	p.log(ctx).Info("Events watermark reset, next run performs a full refresh")
+	return nil
 }
 
 // transformEvent transforms an EONET event to a database record
 func (p *Pipeline) transformEvent(event models.Event) (*models.EventRecord, error) {
-	// Convert CategoryObject array to int array for JSON serialization
-	categoryIDs := make([]int, 0, len(event.Categories))
+	// Convert CategoryObject array to an array of category ids for JSON serialization
+	categoryIDs := make([]string, 0, len(event.Categories))
 	for _, cat := range event.Categories {
-		categoryIDs = append(categoryIDs, cat.GetIDAsInt())
+		if id := cat.GetID(); id != "" && !slices.Contains(categoryIDs, id) {
+			categoryIDs = append(categoryIDs, id)
+		}
 	}
 
 	// Serialize categories to JSON
@@ -202,6 +715,44 @@ func (p *Pipeline) transformEvent(event models.Event) (*models.EventRecord, erro
 		Closed:      event.Closed,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+		CategoryIDs: categoryIDs,
+	}
+
+	// Build the normalized child rows
+	seenSources := make(map[string]bool, len(event.Sources))
+	for _, source := range event.Sources {
+		if source.ID == "" || seenSources[source.ID] {
+			continue
+		}
+		seenSources[source.ID] = true
+		record.SourceLinks = append(record.SourceLinks, models.EventSourceRecord{
+			EventID:  event.ID,
+			SourceID: source.ID,
+			URL:      source.URL,
+		})
+	}
+
+	for i, geometry := range event.Geometry {
+		coordinatesJSON, err := json.Marshal(geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal geometry coordinates: %w", err)
+		}
+
+		row := models.EventGeometryRecord{
+			EventID:              event.ID,
+			Seq:                  i,
+			ObservedAt:           geometry.Date,
+			Type:                 geometry.Type,
+			MagnitudeValue:       geometry.MagnitudeValue,
+			MagnitudeUnit:        geometry.MagnitudeUnit,
+			MagnitudeDescription: geometry.MagnitudeDescription,
+			Coordinates:          string(coordinatesJSON),
+		}
+		if centroid, ok := geometry.Centroid(); ok {
+			row.Longitude = &centroid.Lon
+			row.Latitude = &centroid.Lat
+		}
+		record.Geometries = append(record.Geometries, row)
 	}
 
 	return record, nil
@@ -222,6 +773,11 @@ func (p *Pipeline) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// Store returns the storage backend the pipeline loads into
+func (p *Pipeline) Store() database.Store {
+	return p.db
+}
+
 // GetLastRunInfo returns information about the last ETL run
 func (p *Pipeline) GetLastRunInfo(ctx context.Context) (*database.ETLRunInfo, error) {
 	return p.db.GetLastETLRun(ctx)
@@ -0,0 +1,456 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/metrics"
+	"nasa-data-hub-etl/pkg/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPipeline_WindowStart(t *testing.T) {
+	p := &Pipeline{config: &config.Config{
+		ETL: config.ETLConfig{
+			Overlap:         24 * time.Hour,
+			InitialLookback: 30 * 24 * time.Hour,
+		},
+	}}
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	watermark := time.Date(2025, 1, 30, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		watermark *time.Time
+		want      time.Time
+	}{
+		{
+			name:      "no watermark uses initial lookback",
+			watermark: nil,
+			want:      time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "watermark minus overlap",
+			watermark: &watermark,
+			want:      time.Date(2025, 1, 29, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.windowStart(tt.watermark, now); !got.Equal(tt.want) {
+				t.Errorf("windowStart() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_TransformEventChildRows(t *testing.T) {
+	p := &Pipeline{}
+	acres, unit := 1250.0, "acres"
+	event := models.Event{
+		ID:    "EONET_1",
+		Title: "Wildfire A",
+		Categories: []models.CategoryObject{
+			{ID: "wildfires"},
+			{ID: "wildfires"},
+		},
+		Sources: []models.Source{
+			{ID: "InciWeb", URL: "https://inciweb.example/1"},
+			{ID: "InciWeb", URL: "https://inciweb.example/1"},
+			{ID: "IRWIN", URL: "https://irwin.example/1"},
+		},
+		Geometry: []models.Geometry{
+			{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), Type: models.GeometryPoint, Coordinates: models.Point{Position: models.Position{Lon: -120, Lat: 38}}},
+			{Date: time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC), Type: models.GeometryPoint, MagnitudeValue: &acres, MagnitudeUnit: &unit},
+		},
+	}
+
+	record, err := p.transformEvent(event)
+	if err != nil {
+		t.Fatalf("transformEvent() error = %v", err)
+	}
+
+	if len(record.CategoryIDs) != 1 || record.CategoryIDs[0] != "wildfires" {
+		t.Errorf("CategoryIDs = %v, want [wildfires]", record.CategoryIDs)
+	}
+	if len(record.SourceLinks) != 2 {
+		t.Errorf("SourceLinks = %+v, want 2 distinct sources", record.SourceLinks)
+	}
+	if len(record.Geometries) != 2 {
+		t.Fatalf("Geometries = %+v, want 2 rows", record.Geometries)
+	}
+
+	point := record.Geometries[0]
+	if point.EventID != "EONET_1" || point.Seq != 0 || point.Longitude == nil || *point.Longitude != -120 || *point.Latitude != 38 {
+		t.Errorf("Geometries[0] = %+v, want a positioned point", point)
+	}
+	if point.Coordinates != "[-120,38]" {
+		t.Errorf("Geometries[0].Coordinates = %s, want [-120,38]", point.Coordinates)
+	}
+	second := record.Geometries[1]
+	if second.Seq != 1 || second.Longitude != nil {
+		t.Errorf("Geometries[1] = %+v, want an unpositioned second row", second)
+	}
+	if second.MagnitudeValue == nil || *second.MagnitudeValue != 1250 || *second.MagnitudeUnit != "acres" {
+		t.Errorf("Geometries[1] magnitude = %v %v, want 1250 acres", second.MagnitudeValue, second.MagnitudeUnit)
+	}
+}
+
+// newTestEONETServer serves a fixed category list and event feed
+func newTestEONETServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/categories":
+			fmt.Fprint(w, `{"categories":[{"id":"wildfires","title":"Wildfires"},{"id":"severeStorms","title":"Severe Storms"}]}`)
+		case "/events":
+			fmt.Fprint(w, `{"events":[
+				{"id":"EONET_1","title":"Wildfire A","categories":[{"id":"wildfires"}],
+				 "geometry":[{"date":"2025-01-20T00:00:00Z","type":"Point","coordinates":[-120,38]}]},
+				{"id":"EONET_2","title":"Storm B","categories":[{"id":"severeStorms"}],
+				 "geometry":[{"date":"2025-01-22T06:00:00Z","type":"Point","coordinates":[-80,25]}]}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestPipeline(t *testing.T, apiURL string, store database.Store) *Pipeline {
+	t.Helper()
+
+	cfg := &config.Config{
+		NASA: config.NASAConfig{APIURL: apiURL},
+		ETL: config.ETLConfig{
+			BatchSize:       100,
+			Overlap:         time.Hour,
+			InitialLookback: 24 * time.Hour,
+		},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewPipelineWithStore(cfg, store, logger)
+}
+
+func TestPipeline_RunWithMemoryStore(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, newTestEONETServer(t).URL, store)
+	ctx := context.Background()
+
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	events := store.Events()
+	if len(events) != 2 || events[0].ID != "EONET_1" || events[1].ID != "EONET_2" {
+		t.Fatalf("stored events = %+v, want EONET_1 and EONET_2", events)
+	}
+	if len(store.Categories()) != 2 {
+		t.Errorf("stored %d categories, want 2", len(store.Categories()))
+	}
+
+	watermark, err := store.GetWatermark(ctx, eventsWatermarkSource)
+	if err != nil || watermark == nil || !watermark.Equal(time.Date(2025, 1, 22, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("watermark = %v, %v, want the latest geometry date", watermark, err)
+	}
+
+	run, err := p.GetLastRunInfo(ctx)
+	if err != nil || run == nil {
+		t.Fatalf("GetLastRunInfo() = %v, %v", run, err)
+	}
+	if run.Status != "completed" || run.EventsProcessed != 2 || run.CompletedAt == nil {
+		t.Errorf("last run = %+v, want completed with 2 events", run)
+	}
+	if run.CategoriesProcessed != 2 {
+		t.Errorf("last run processed %d categories, want 2", run.CategoriesProcessed)
+	}
+	if run.APIRequests != 2 || run.BytesDownloaded == 0 {
+		t.Errorf("last run made %d requests for %d bytes, want 2 requests", run.APIRequests, run.BytesDownloaded)
+	}
+	if run.AppVersion == "" || run.ConfigHash != p.config.Hash() {
+		t.Errorf("last run version = %q, config hash = %q", run.AppVersion, run.ConfigHash)
+	}
+
+	// A second run sees the same events and changes nothing
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if len(store.Events()) != 2 {
+		t.Errorf("second run stored %d events, want 2", len(store.Events()))
+	}
+}
+
+func TestPipeline_RunUpdatesMetrics(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, newTestEONETServer(t).URL, store)
+
+	// Collectors are process wide, so compare against their values before the run
+	counters := map[string]prometheus.Collector{
+		"runs completed":     metrics.RunsTotal.WithLabelValues("completed"),
+		"events fetched":     metrics.EventsFetched,
+		"events transformed": metrics.EventsTransformed,
+		"events inserted":    metrics.EventsLoaded.WithLabelValues("inserted"),
+		"events requests":    metrics.APIRequests.WithLabelValues("events", "200"),
+	}
+	want := map[string]float64{
+		"runs completed":     1,
+		"events fetched":     2,
+		"events transformed": 2,
+		"events inserted":    2,
+		"events requests":    1,
+	}
+	before := make(map[string]float64, len(counters))
+	for name, c := range counters {
+		before[name] = testutil.ToFloat64(c)
+	}
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	for name, c := range counters {
+		if got := testutil.ToFloat64(c) - before[name]; got != want[name] {
+			t.Errorf("%s grew by %v, want %v", name, got, want[name])
+		}
+	}
+	if got := testutil.ToFloat64(metrics.OpenEvents); got != 2 {
+		t.Errorf("open events = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.LastSuccess); got < float64(time.Now().Add(-time.Minute).Unix()) {
+		t.Errorf("last success = %v, want about now", got)
+	}
+}
+
+func TestPipeline_RunTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	p := newTestPipeline(t, newTestEONETServer(t).URL, database.NewMemoryStore())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	run, ok := spans["etl.run"]
+	if !ok {
+		t.Fatalf("no etl.run span among %v", slices.Collect(maps.Keys(spans)))
+	}
+	for _, name := range []string{"etl.process_categories", "etl.process_events", "eonet.fetch_events", "GET events", "etl.transform_events"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if span.SpanContext().TraceID() != run.SpanContext().TraceID() {
+			t.Errorf("%s span is not part of the run trace", name)
+		}
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans["etl.process_events"].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["etl.records.inserted"].AsInt64() != 2 {
+		t.Errorf("etl.process_events attributes = %v, want 2 inserted", attrs)
+	}
+	if code := spans["GET events"].Status().Code; code == codes.Error {
+		t.Errorf("GET events span status = %v, want unset", code)
+	}
+}
+
+func TestPipeline_RunRecordsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, server.URL, store)
+
+	if err := p.Run(context.Background()); err == nil {
+		t.Fatal("Run() expected error")
+	}
+
+	run, _ := store.GetLastETLRun(context.Background())
+	if run == nil || run.Status != "failed" || run.ErrorMessage == nil {
+		t.Errorf("last run = %+v, want a failed run with an error message", run)
+	}
+}
+
+func TestPipeline_TriggerWithOverrides(t *testing.T) {
+	release := make(chan struct{})
+	queries := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/categories":
+			fmt.Fprint(w, `{"categories":[{"id":"wildfires","title":"Wildfires"}]}`)
+		case "/events":
+			queries <- r.URL.RawQuery
+			<-release
+			fmt.Fprint(w, `{"events":[{"id":"EONET_1","title":"Wildfire A","categories":[{"id":"wildfires"}],
+				"geometry":[{"date":"2025-01-20T00:00:00Z","type":"Point","coordinates":[-120,38]}]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, server.URL, store)
+	ctx := context.Background()
+
+	if _, err := p.Trigger(ctx, RunOptions{Status: "pending"}); err == nil {
+		t.Error("Trigger() with an invalid status expected error")
+	}
+
+	runID, err := p.Trigger(ctx, RunOptions{Days: 3, Status: "open", Category: "wildfires"})
+	if err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+
+	query := <-queries
+	for _, want := range []string{"days=3", "status=open", "category=wildfires"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("events query = %q, want %s", query, want)
+		}
+	}
+	if strings.Contains(query, "start=") {
+		t.Errorf("events query = %q, want no start with days set", query)
+	}
+
+	// Only one run at a time, scheduled or triggered
+	if _, err := p.Trigger(ctx, RunOptions{}); !errors.Is(err, ErrRunInProgress) {
+		t.Errorf("second Trigger() error = %v, want ErrRunInProgress", err)
+	}
+	if err := p.Run(ctx); !errors.Is(err, ErrRunInProgress) {
+		t.Errorf("Run() during triggered run error = %v, want ErrRunInProgress", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for p.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	run, err := store.GetETLRun(ctx, runID)
+	if err != nil || run == nil || run.Status != "completed" || run.EventsProcessed != 1 {
+		t.Fatalf("triggered run = %+v, %v, want completed with 1 event", run, err)
+	}
+
+	// Overrides load a partial view of the feed, so the watermark stays put
+	if watermark, _ := store.GetWatermark(ctx, eventsWatermarkSource); watermark != nil {
+		t.Errorf("watermark = %v after a run with overrides, want none", watermark)
+	}
+}
+
+func TestPipeline_RunLogsCorrelationFields(t *testing.T) {
+	store := database.NewMemoryStore()
+	p := newTestPipeline(t, newTestEONETServer(t).URL, store)
+	p.logger.SetLevel(logrus.DebugLevel)
+	hook := logtest.NewLocal(p.logger)
+
+	ctx := logger.WithField(context.Background(), logger.FieldHTTPRequestID, "req-1")
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	run, _ := store.GetLastETLRun(ctx)
+
+	phases := make(map[any]bool)
+	for _, entry := range hook.AllEntries() {
+		if entry.Data[logger.FieldRunID] != run.ID {
+			t.Errorf("entry %q has run_id %v, want %d", entry.Message, entry.Data[logger.FieldRunID], run.ID)
+		}
+		if entry.Data[logger.FieldHTTPRequestID] != "req-1" {
+			t.Errorf("entry %q has http_request_id %v, want req-1", entry.Message, entry.Data[logger.FieldHTTPRequestID])
+		}
+		phases[entry.Data[logger.FieldPhase]] = true
+	}
+	for _, phase := range []string{runPhase, "extract", "transform", "load"} {
+		if !phases[phase] {
+			t.Errorf("no entry logged in phase %s, got phases %v", phase, phases)
+		}
+	}
+}
+
+// batchRecordingStore records the size of every event upsert
+type batchRecordingStore struct {
+	database.Store
+	batches []int
+}
+
+func (s *batchRecordingStore) UpsertEvents(ctx context.Context, events []*models.EventRecord) (database.UpsertResult, error) {
+	s.batches = append(s.batches, len(events))
+	return s.Store.UpsertEvents(ctx, events)
+}
+
+func TestPipeline_BackfillPagesAndLoadsInBatches(t *testing.T) {
+	// One event per date, the server applies start, end and limit like EONET
+	dates := []string{"2024-01-02", "2024-01-05", "2024-01-11", "2024-01-17", "2024-01-23", "2024-01-30"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		events := make([]string, 0, limit)
+		for i, date := range dates {
+			if date < query.Get("start") || date > query.Get("end") || len(events) == limit {
+				continue
+			}
+			events = append(events, fmt.Sprintf(`{"id":"EONET_%d","geometry":[{"date":"%sT00:00:00Z","type":"Point","coordinates":[1,2]}]}`, i, date))
+		}
+		fmt.Fprintf(w, `{"events":[%s]}`, strings.Join(events, ","))
+	}))
+	defer server.Close()
+
+	store := &batchRecordingStore{Store: database.NewMemoryStore()}
+	p := newTestPipeline(t, server.URL, store)
+	p.config.ETL.BatchSize = 2
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := p.Backfill(context.Background(), from, from.AddDate(0, 1, 0), "month"); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+
+	stored, err := store.CountEvents(context.Background(), database.EventFilter{})
+	if err != nil || stored != int64(len(dates)) {
+		t.Errorf("stored %d events, %v, want %d", stored, err, len(dates))
+	}
+	for _, size := range store.batches {
+		if size > p.config.ETL.BatchSize {
+			t.Errorf("upsert batches = %v, want at most %d events each", store.batches, p.config.ETL.BatchSize)
+			break
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Runner is anything the scheduler can trigger, normally a *Pipeline
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// Scheduler triggers a Runner every interval until its context is cancelled
+type Scheduler struct {
+	runner   Runner
+	interval time.Duration
+	logger   *logrus.Logger
+
+	running atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a new scheduler for the given runner
+func NewScheduler(runner Runner, interval time.Duration, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		runner:   runner,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start runs the runner immediately and then on every tick. A tick is skipped
+// if the previous run is still in progress. When ctx is cancelled the in-flight
+// run (if any) is cancelled too, and Start returns once it has finished.
+func (s *Scheduler) Start(ctx context.Context) error {
+	if s.interval <= 0 {
+		return fmt.Errorf("scheduler interval must be greater than 0, got %s", s.interval)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.WithField("interval", s.interval.String()).Info("ETL scheduler started")
+
+	s.trigger(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("ETL scheduler stopping, waiting for in-flight run to finish")
+			s.wg.Wait()
+			return nil
+		case <-ticker.C:
+			s.trigger(ctx)
+		}
+	}
+}
+
+// IsRunning reports whether a scheduled run is currently in progress
+func (s *Scheduler) IsRunning() bool {
+	return s.running.Load()
+}
+
+// trigger starts a run in the background unless one is already in progress
+func (s *Scheduler) trigger(ctx context.Context) {
+	if !s.running.CompareAndSwap(false, true) {
+		s.logger.Warn("Previous ETL run still in progress, skipping scheduled run")
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Store(false)
+
+		started := time.Now()
+		err := s.runner.Run(ctx)
+		if errors.Is(err, ErrRunInProgress) {
+			s.logger.Warn("An on-demand ETL run is in progress, skipping scheduled run")
+			return
+		}
+		if errors.Is(err, ErrNotLeader) {
+			s.logger.Debug("Not the leader, skipping scheduled ETL run")
+			return
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("duration", time.Since(started).String()).Error("Scheduled ETL run failed")
+			return
+		}
+
+		s.logger.WithField("duration", time.Since(started).String()).Info("Scheduled ETL run finished")
+	}()
+}
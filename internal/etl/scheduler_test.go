@@ -0,0 +1,134 @@
+package etl
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// blockingRunner counts runs and blocks each one until release is closed or ctx is done
+type blockingRunner struct {
+	runs    atomic.Int32
+	release chan struct{}
+}
+
+func (r *blockingRunner) Run(ctx context.Context) error {
+	r.runs.Add(1)
+	select {
+	case <-r.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestScheduler_InvalidInterval(t *testing.T) {
+	scheduler := NewScheduler(&blockingRunner{}, 0, logrus.New())
+
+	if err := scheduler.Start(context.Background()); err == nil {
+		t.Error("Start() should fail with zero interval")
+	}
+}
+
+func TestScheduler_SkipsTickWhileRunning(t *testing.T) {
+	runner := &blockingRunner{release: make(chan struct{})}
+	scheduler := NewScheduler(runner, 10*time.Millisecond, logrus.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Start(ctx)
+	}()
+
+	// Let several ticks pass while the first run is blocked
+	time.Sleep(100 * time.Millisecond)
+
+	if got := runner.runs.Load(); got != 1 {
+		t.Errorf("runs = %d, want 1 while previous run is in progress", got)
+	}
+	if !scheduler.IsRunning() {
+		t.Error("IsRunning() = false, want true")
+	}
+
+	// Once the run finishes the next tick should start a new one
+	close(runner.release)
+	time.Sleep(50 * time.Millisecond)
+
+	if got := runner.runs.Load(); got < 2 {
+		t.Errorf("runs = %d, want at least 2 after release", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Start() did not return after cancellation")
+	}
+}
+
+func TestScheduler_StopWaitsForInFlightRun(t *testing.T) {
+	runner := &blockingRunner{release: make(chan struct{})}
+	scheduler := NewScheduler(runner, time.Hour, logrus.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Start(ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start() did not return after cancellation")
+	}
+
+	if scheduler.IsRunning() {
+		t.Error("IsRunning() = true after Start() returned")
+	}
+}
+
+// standbyRunner fails every run like a pipeline without the leader lease
+type standbyRunner struct{}
+
+func (standbyRunner) Run(context.Context) error {
+	return ErrNotLeader
+}
+
+func TestScheduler_StandbySkipsRun(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	scheduler := NewScheduler(standbyRunner{}, time.Hour, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Start(ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	var skipped bool
+	for _, entry := range hook.AllEntries() {
+		switch entry.Message {
+		case "Scheduled ETL run finished", "Scheduled ETL run failed":
+			t.Errorf("standby logged %q", entry.Message)
+		case "Not the leader, skipping scheduled ETL run":
+			skipped = entry.Level == logrus.DebugLevel
+		}
+	}
+	if !skipped {
+		t.Error("standby did not log the skipped run at debug level")
+	}
+}
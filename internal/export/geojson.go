@@ -0,0 +1,175 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/pkg/models"
+)
+
+// defaultPageSize is the number of events read from the store at a time
+const defaultPageSize = 500
+
+// Options controls a GeoJSON export
+type Options struct {
+	// LatestOnly exports only the most recent matching observation of each event
+	LatestOnly bool
+	// PageSize is the number of events read at a time, defaultPageSize if 0
+	PageSize int
+}
+
+// Feature is a GeoJSON Feature for one observation of an event
+type Feature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *featureGeometry  `json:"geometry"`
+	Properties FeatureProperties `json:"properties"`
+}
+
+type featureGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// FeatureProperties describes the event and the observation of a Feature
+type FeatureProperties struct {
+	EventID              string          `json:"id"`
+	Title                string          `json:"title"`
+	Status               string          `json:"status"`
+	Closed               *string         `json:"closed,omitempty"`
+	Categories           []string        `json:"categories"`
+	Sources              []FeatureSource `json:"sources"`
+	Seq                  int             `json:"seq"`
+	Date                 time.Time       `json:"date"`
+	MagnitudeValue       *float64        `json:"magnitude_value,omitempty"`
+	MagnitudeUnit        *string         `json:"magnitude_unit,omitempty"`
+	MagnitudeDescription *string         `json:"magnitude_description,omitempty"`
+}
+
+// FeatureSource is a data source of an event
+type FeatureSource struct {
+	ID  string `json:"id"`
+	URL string `json:"url,omitempty"`
+}
+
+// Features converts the observations of an event that match filter. With
+// latestOnly only the most recent one is returned.
+func Features(event *models.EventRecord, filter database.EventFilter, latestOnly bool) []Feature {
+	var geometries []models.EventGeometryRecord
+	for _, g := range event.Geometries {
+		if filter.MatchesGeometry(g) {
+			geometries = append(geometries, g)
+		}
+	}
+
+	if latestOnly && len(geometries) > 1 {
+		latest := geometries[0]
+		for _, g := range geometries[1:] {
+			if !g.ObservedAt.Before(latest.ObservedAt) {
+				latest = g
+			}
+		}
+		geometries = []models.EventGeometryRecord{latest}
+	}
+
+	status := database.EventStatusOpen
+	if event.Closed != nil {
+		status = database.EventStatusClosed
+	}
+
+	categories := event.CategoryIDs
+	if categories == nil {
+		categories = []string{}
+	}
+
+	sources := make([]FeatureSource, 0, len(event.SourceLinks))
+	for _, source := range event.SourceLinks {
+		sources = append(sources, FeatureSource{ID: source.SourceID, URL: source.URL})
+	}
+
+	features := make([]Feature, 0, len(geometries))
+	for _, g := range geometries {
+		feature := Feature{
+			Type: "Feature",
+			ID:   fmt.Sprintf("%s/%d", event.ID, g.Seq),
+			Properties: FeatureProperties{
+				EventID:              event.ID,
+				Title:                event.Title,
+				Status:               status,
+				Closed:               event.Closed,
+				Categories:           categories,
+				Sources:              sources,
+				Seq:                  g.Seq,
+				Date:                 g.ObservedAt.UTC(),
+				MagnitudeValue:       g.MagnitudeValue,
+				MagnitudeUnit:        g.MagnitudeUnit,
+				MagnitudeDescription: g.MagnitudeDescription,
+			},
+		}
+		if g.Coordinates != "" && g.Coordinates != "null" {
+			feature.Geometry = &featureGeometry{Type: g.Type, Coordinates: json.RawMessage(g.Coordinates)}
+		}
+		features = append(features, feature)
+	}
+
+	return features
+}
+
+// WriteGeoJSON streams the events matching filter to w as a GeoJSON
+// FeatureCollection with one Feature per observation. Events are read a page
+// at a time, so memory use does not grow with the result. filter.Limit and
+// filter.Offset are ignored. It returns the number of features written.
+func WriteGeoJSON(ctx context.Context, store database.QueryStore, filter database.EventFilter, w io.Writer, opts Options) (int, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	filter.Limit, filter.Offset = pageSize, 0
+
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return 0, fmt.Errorf("failed to write GeoJSON: %w", err)
+	}
+
+	written := 0
+	for {
+		events, err := store.ListEvents(ctx, filter)
+		if err != nil {
+			return written, err
+		}
+
+		for _, event := range events {
+			for _, feature := range Features(event, filter, opts.LatestOnly) {
+				data, err := json.Marshal(feature)
+				if err != nil {
+					return written, fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+				}
+				if written > 0 {
+					data = append([]byte{','}, data...)
+				}
+				if _, err := w.Write(append(data, '\n')); err != nil {
+					return written, fmt.Errorf("failed to write GeoJSON: %w", err)
+				}
+				written++
+			}
+		}
+
+		// Let HTTP clients start reading the page
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+
+		if len(events) < pageSize {
+			break
+		}
+		filter.AfterID = events[len(events)-1].ID
+	}
+
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return written, fmt.Errorf("failed to write GeoJSON: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/pkg/models"
+)
+
+func newTestStore(t *testing.T) *database.MemoryStore {
+	t.Helper()
+
+	lon, lat := -120.0, 38.0
+	magnitude := 1500.0
+	unit := "acres"
+	closed := "2024-07-10T00:00:00Z"
+
+	store := database.NewMemoryStore()
+	_, err := store.UpsertEvents(context.Background(), []*models.EventRecord{
+		{
+			ID:          "EONET_1",
+			Title:       "Wildfire",
+			CategoryIDs: []string{"wildfires"},
+			SourceLinks: []models.EventSourceRecord{{EventID: "EONET_1", SourceID: "InciWeb", URL: "https://example.com"}},
+			Geometries: []models.EventGeometryRecord{
+				{EventID: "EONET_1", Seq: 0, ObservedAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Type: "Point", Longitude: &lon, Latitude: &lat, Coordinates: "[-120,38]"},
+				{EventID: "EONET_1", Seq: 1, ObservedAt: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), Type: "Point", Longitude: &lon, Latitude: &lat, Coordinates: "[-120,38]",
+					MagnitudeValue: &magnitude, MagnitudeUnit: &unit},
+			},
+		},
+		{
+			ID:          "EONET_2",
+			Title:       "Volcano",
+			Closed:      &closed,
+			CategoryIDs: []string{"volcanoes"},
+			Geometries: []models.EventGeometryRecord{
+				{EventID: "EONET_2", Seq: 0, ObservedAt: time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC), Type: "Polygon", Coordinates: "[[[0,0],[1,0],[1,1],[0,0]]]"},
+			},
+		},
+		{ID: "EONET_3", Title: "No observations"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEvents() error = %v", err)
+	}
+	return store
+}
+
+// featureCollection decodes an export, failing the test if it is not valid JSON
+func featureCollection(t *testing.T, data []byte) []Feature {
+	t.Helper()
+
+	var collection struct {
+		Type     string    `json:"type"`
+		Features []Feature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil || collection.Type != "FeatureCollection" {
+		t.Fatalf("export is not a FeatureCollection: %v\n%s", err, data)
+	}
+	return collection.Features
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	store := newTestStore(t)
+
+	var buf bytes.Buffer
+	// A page size of one makes the export page through every event
+	n, err := WriteGeoJSON(context.Background(), store, database.EventFilter{}, &buf, Options{PageSize: 1})
+	if err != nil {
+		t.Fatalf("WriteGeoJSON() error = %v", err)
+	}
+
+	features := featureCollection(t, buf.Bytes())
+	if n != 3 || len(features) != 3 {
+		t.Fatalf("WriteGeoJSON() wrote %d features, decoded %d, want 3", n, len(features))
+	}
+
+	second := features[1]
+	if second.ID != "EONET_1/1" || second.Properties.EventID != "EONET_1" || second.Properties.Status != "open" {
+		t.Errorf("features[1] = %+v", second)
+	}
+	if second.Properties.MagnitudeValue == nil || *second.Properties.MagnitudeValue != 1500 || len(second.Properties.Sources) != 1 {
+		t.Errorf("features[1] properties = %+v", second.Properties)
+	}
+	if second.Geometry == nil || second.Geometry.Type != "Point" || string(second.Geometry.Coordinates) != "[-120,38]" {
+		t.Errorf("features[1] geometry = %+v", second.Geometry)
+	}
+	if features[2].Properties.Status != "closed" || features[2].Geometry.Type != "Polygon" {
+		t.Errorf("features[2] = %+v", features[2])
+	}
+}
+
+func TestWriteGeoJSON_LatestAndFilter(t *testing.T) {
+	store := newTestStore(t)
+
+	var buf bytes.Buffer
+	if _, err := WriteGeoJSON(context.Background(), store, database.EventFilter{}, &buf, Options{LatestOnly: true}); err != nil {
+		t.Fatalf("WriteGeoJSON() error = %v", err)
+	}
+	features := featureCollection(t, buf.Bytes())
+	if len(features) != 2 || features[0].ID != "EONET_1/1" || features[1].ID != "EONET_2/0" {
+		t.Errorf("latest features = %+v", features)
+	}
+
+	// Only the observation within the window is exported
+	to := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+	buf.Reset()
+	if _, err := WriteGeoJSON(context.Background(), store, database.EventFilter{To: &to}, &buf, Options{LatestOnly: true}); err != nil {
+		t.Fatalf("WriteGeoJSON() error = %v", err)
+	}
+	features = featureCollection(t, buf.Bytes())
+	if len(features) != 1 || features[0].ID != "EONET_1/0" {
+		t.Errorf("filtered features = %+v", features)
+	}
+}
+
+func TestWriteGeoJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := WriteGeoJSON(context.Background(), database.NewMemoryStore(), database.EventFilter{}, &buf, Options{})
+	if err != nil || n != 0 || len(featureCollection(t, buf.Bytes())) != 0 {
+		t.Errorf("WriteGeoJSON() on an empty store = %d, %v, %s", n, err, buf.String())
+	}
+}
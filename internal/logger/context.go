@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Correlation fields carried by contexts, so that the log lines of one run or
+// one HTTP request can be grouped
+const (
+	FieldRunID         = "run_id"
+	FieldPhase         = "phase"
+	FieldEventID       = "event_id"
+	FieldHTTPRequestID = "http_request_id"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx whose log entries carry fields in addition
+// to the fields already in ctx
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	for k, v := range Fields(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithField returns a copy of ctx whose log entries carry key
+func WithField(ctx context.Context, key string, value any) context.Context {
+	return WithFields(ctx, logrus.Fields{key: value})
+}
+
+// Fields returns the fields carried by ctx. The result must not be modified.
+func Fields(ctx context.Context) logrus.Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// FromContext returns an entry of log with the fields carried by ctx
+func FromContext(ctx context.Context, log *logrus.Logger) *logrus.Entry {
+	return log.WithContext(ctx).WithFields(Fields(ctx))
+}
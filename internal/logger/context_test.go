@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestWithFields_Merges(t *testing.T) {
+	parent := WithFields(context.Background(), logrus.Fields{FieldRunID: int64(1), FieldPhase: "extract"})
+	child := WithField(parent, FieldPhase, "load")
+
+	if got := Fields(child); got[FieldRunID] != int64(1) || got[FieldPhase] != "load" {
+		t.Errorf("child fields = %v, want run_id 1 and phase load", got)
+	}
+	if got := Fields(parent); got[FieldPhase] != "extract" {
+		t.Errorf("parent phase = %v after WithField on the child, want extract", got[FieldPhase])
+	}
+	if got := Fields(context.Background()); len(got) != 0 {
+		t.Errorf("fields of an empty context = %v, want none", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+
+	ctx := WithField(context.Background(), FieldHTTPRequestID, "abc")
+	FromContext(ctx, log).WithField("count", 2).Info("done")
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Data[FieldHTTPRequestID] != "abc" || entry.Data["count"] != 2 {
+		t.Fatalf("entry = %+v, want http_request_id and count", entry)
+	}
+	if entry.Context != ctx {
+		t.Error("entry does not carry the context")
+	}
+}
@@ -1,9 +1,14 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
 
+	"nasa-data-hub-etl/internal/config"
+
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // New creates a new logger instance
@@ -11,15 +16,7 @@ func New() *logrus.Logger {
 	log := logrus.New()
 
 	// Set JSON formatter for structured logging
-	log.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyTime:  "timestamp",
-			logrus.FieldKeyLevel: "level",
-			logrus.FieldKeyMsg:   "message",
-			logrus.FieldKeyFunc:  "function",
-		},
-	})
+	log.SetFormatter(newFormatter(config.LogFormatJSON, false))
 
 	// Set output to stdout
 	log.SetOutput(os.Stdout)
@@ -38,3 +35,88 @@ func New() *logrus.Logger {
 
 	return log
 }
+
+// Configure applies the level and format of cfg to log and adds the rotating
+// log file if one is configured. The returned function closes the file.
+func Configure(log *logrus.Logger, cfg config.LogConfig) (closeFile func() error, err error) {
+	level := logrus.InfoLevel
+	if cfg.Level != "" {
+		if level, err = logrus.ParseLevel(cfg.Level); err != nil {
+			return nil, fmt.Errorf("failed to parse log level: %w", err)
+		}
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = config.LogFormatJSON
+	}
+	if format != config.LogFormatJSON && format != config.LogFormatText {
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	log.SetLevel(level)
+	log.SetFormatter(newFormatter(format, true))
+
+	if cfg.File.Path == "" {
+		return func() error { return nil }, nil
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   cfg.File.Path,
+		MaxSize:    cfg.File.MaxSizeMB,
+		MaxBackups: cfg.File.MaxBackups,
+		MaxAge:     cfg.File.MaxAgeDays,
+		Compress:   cfg.File.Compress,
+	}
+	// The file is a hook rather than part of the output, so that commands
+	// which redirect the output keep writing it
+	log.AddHook(&fileHook{writer: file, formatter: newFormatter(format, false)})
+
+	return file.Close, nil
+}
+
+// newFormatter returns the formatter of format. Text is colored when colors is
+// set and the output is a terminal.
+func newFormatter(format string, colors bool) logrus.Formatter {
+	if format == config.LogFormatText {
+		return &logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: "15:04:05.000",
+			DisableColors:   !colors,
+		}
+	}
+
+	return &logrus.JSONFormatter{
+		TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyTime:  "timestamp",
+			logrus.FieldKeyLevel: "level",
+			logrus.FieldKeyMsg:   "message",
+			logrus.FieldKeyFunc:  "function",
+		},
+	}
+}
+
+// fileHook writes every entry that passes the level of the logger to a file
+type fileHook struct {
+	writer    io.Writer
+	formatter logrus.Formatter
+}
+
+// Levels returns all levels, the logger filters entries before hooks fire
+func (h *fileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes entry to the file
+func (h *fileHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return fmt.Errorf("failed to format log entry: %w", err)
+	}
+
+	if _, err := h.writer.Write(line); err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+	return nil
+}
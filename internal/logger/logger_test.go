@@ -1,8 +1,15 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"nasa-data-hub-etl/internal/config"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -91,3 +98,73 @@ func TestNewLogger_JSONFormatter(t *testing.T) {
 		t.Error("NewLogger() should use JSONFormatter")
 	}
 }
+
+func TestConfigure_TextFormatAndLevel(t *testing.T) {
+	log := New()
+
+	closeFile, err := Configure(log, config.LogConfig{Level: "debug", Format: config.LogFormatText})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	defer closeFile()
+
+	if _, ok := log.Formatter.(*logrus.TextFormatter); !ok {
+		t.Errorf("formatter = %T, want *logrus.TextFormatter", log.Formatter)
+	}
+	if log.GetLevel() != logrus.DebugLevel {
+		t.Errorf("level = %v, want debug", log.GetLevel())
+	}
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	for _, cfg := range []config.LogConfig{
+		{Level: "verbose"},
+		{Format: "xml"},
+	} {
+		if _, err := Configure(New(), cfg); err == nil {
+			t.Errorf("Configure(%+v) expected error", cfg)
+		}
+	}
+}
+
+func TestConfigure_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "etl.log")
+	log := New()
+
+	closeFile, err := Configure(log, config.LogConfig{
+		Level:  "info",
+		Format: config.LogFormatJSON,
+		File:   config.LogFileConfig{Path: path, MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	// Redirecting the output keeps the file
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.WithField("run_id", 7).Info("Run finished")
+	log.Debug("Below the level")
+	if err := closeFile(); err != nil {
+		t.Fatalf("closing the log file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the log file: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 {
+		t.Fatalf("log file has %d lines, want 1: %s", len(lines), data)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("log file line is not JSON: %v", err)
+	}
+	if entry["message"] != "Run finished" || entry["run_id"] != float64(7) {
+		t.Errorf("log file entry = %v, want the run message with run_id", entry)
+	}
+	if !strings.Contains(out.String(), "Run finished") {
+		t.Errorf("output = %q, want the entry as well", out.String())
+	}
+}
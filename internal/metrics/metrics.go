@@ -0,0 +1,124 @@
+// Package metrics holds the Prometheus collectors that the pipeline and the
+// EONET client update while they work. They are served from /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all collectors of this process, including the Go runtime
+// and process collectors
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// Run metrics
+var (
+	RunsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "etl_runs_total",
+		Help: "ETL runs by final status: completed, failed or cancelled",
+	}, []string{"status"})
+
+	RunDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Name:    "etl_run_duration_seconds",
+		Help:    "Duration of ETL runs",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	})
+
+	LastSuccess = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "etl_last_success_timestamp_seconds",
+		Help: "Unix time at which the last successful ETL run finished",
+	})
+
+	RunsAbandoned = factory.NewCounter(prometheus.CounterOpts{
+		Name: "etl_runs_abandoned_total",
+		Help: "Stale runs marked abandoned at startup because their heartbeat stopped",
+	})
+
+	Leader = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "etl_leader",
+		Help: "Whether this instance holds the leader lease and runs the pipeline",
+	})
+)
+
+// Event metrics
+var (
+	EventsFetched = factory.NewCounter(prometheus.CounterOpts{
+		Name: "etl_events_fetched_total",
+		Help: "Events read from the EONET API",
+	})
+
+	EventsTransformed = factory.NewCounter(prometheus.CounterOpts{
+		Name: "etl_events_transformed_total",
+		Help: "Events transformed to database records",
+	})
+
+	EventsSkipped = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "etl_events_skipped_total",
+		Help: "Events dropped by reason: invalid in the API response or failed to transform",
+	}, []string{"reason"})
+
+	GeometriesSkipped = factory.NewCounter(prometheus.CounterOpts{
+		Name: "etl_geometries_skipped_total",
+		Help: "Invalid observations left out of events that were kept",
+	})
+
+	EventsLoaded = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "etl_events_loaded_total",
+		Help: "Events upserted into the store by result: inserted, updated or unchanged",
+	}, []string{"result"})
+
+	OpenEvents = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "etl_open_events",
+		Help: "Events in the store that are not closed, as of the last run",
+	})
+)
+
+// API and database metrics
+var (
+	APIRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "eonet_api_requests_total",
+		Help: "Requests to the EONET API by endpoint and HTTP status code, code is \"error\" if no response arrived",
+	}, []string{"endpoint", "code"})
+
+	APILatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "eonet_api_request_duration_seconds",
+		Help:    "Latency of EONET API requests by endpoint, including reading the body",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"endpoint"})
+
+	DBBatchLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "etl_db_batch_duration_seconds",
+		Help:    "Latency of batch writes to the store by operation",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"operation"})
+)
+
+// Label values of EventsSkipped
+const (
+	SkipInvalid   = "invalid"
+	SkipTransform = "transform"
+)
+
+// Label values of DBBatchLatency
+const (
+	OpUpsertEvents     = "upsert_events"
+	OpUpsertCategories = "upsert_categories"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
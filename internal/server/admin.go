@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logLevel is the body of GET and PUT /admin/log-level
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// getLogLevelHandler serves GET /admin/log-level
+func (s *Server) getLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevel{Level: s.logger.GetLevel().String()})
+}
+
+// setLogLevelHandler serves PUT /admin/log-level. The level applies to the
+// whole process right away, including runs in progress, until the next
+// change or restart.
+func (s *Server) setLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	var body logLevel
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid log level request: %v", err))
+		return
+	}
+
+	level, err := logrus.ParseLevel(body.Level)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	previous := s.logger.GetLevel()
+	s.logger.SetLevel(level)
+	s.log(r).WithFields(logrus.Fields{
+		"from": previous.String(),
+		"to":   level.String(),
+	}).Warn("Changed log level")
+
+	writeJSON(w, http.StatusOK, logLevel{Level: level.String()})
+}
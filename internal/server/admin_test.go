@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestServer_AdminDisabledByDefault(t *testing.T) {
+	server, _, _ := newTestServer(t)
+
+	if handler := server.AdminHandler(); handler != nil {
+		t.Error("AdminHandler() without server.admin_port should be nil")
+	}
+
+	// The public API does not serve the admin endpoints either way
+	server.config.Server.AdminPort = 9090
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("PUT /admin/log-level on the API = %d, want 404", rec.Code)
+	}
+	if level := server.logger.GetLevel(); level != logrus.InfoLevel {
+		t.Errorf("logger level = %v, want info", level)
+	}
+}
+
+func TestServer_LogLevel(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	server.config.Server.AdminPort = 9090
+	handler := server.AdminHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/log-level", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"level":"info"`) {
+		t.Errorf("GET /admin/log-level = %d %s, want 200 info", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"level":"debug"`) {
+		t.Errorf("PUT /admin/log-level debug = %d %s, want 200 debug", rec.Code, rec.Body.String())
+	}
+	if level := server.logger.GetLevel(); level != logrus.DebugLevel {
+		t.Errorf("logger level = %v after PUT, want debug", level)
+	}
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":"info","extra":1}`, `not json`} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT /admin/log-level %s = %d, want 400", body, rec.Code)
+		}
+	}
+	if level := server.logger.GetLevel(); level != logrus.DebugLevel {
+		t.Errorf("logger level = %v after invalid requests, want debug", level)
+	}
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/export"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/pkg/models"
+)
+
+// Paging limits of GET /api/v1/events
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// eventResponse is the API representation of an event
+type eventResponse struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Link        string             `json:"link,omitempty"`
+	Status      string             `json:"status"`
+	Closed      *string            `json:"closed,omitempty"`
+	Categories  []string           `json:"categories"`
+	Sources     []sourceResponse   `json:"sources"`
+	Geometries  []geometryResponse `json:"geometries"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type sourceResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url,omitempty"`
+}
+
+type geometryResponse struct {
+	Date                 time.Time       `json:"date"`
+	Type                 string          `json:"type"`
+	Coordinates          json.RawMessage `json:"coordinates"`
+	MagnitudeValue       *float64        `json:"magnitude_value,omitempty"`
+	MagnitudeUnit        *string         `json:"magnitude_unit,omitempty"`
+	MagnitudeDescription *string         `json:"magnitude_description,omitempty"`
+}
+
+// eventPage is the response of GET /api/v1/events
+type eventPage struct {
+	Events     []eventResponse `json:"events"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	NextOffset *int            `json:"next_offset,omitempty"` // Set if more events match
+}
+
+type categoryResponse struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Link        string `json:"link,omitempty"`
+	Description string `json:"description,omitempty"`
+	Layers      string `json:"layers,omitempty"`
+}
+
+func newEventResponse(event *models.EventRecord) eventResponse {
+	resp := eventResponse{
+		ID:          event.ID,
+		Title:       event.Title,
+		Description: event.Description,
+		Link:        event.Link,
+		Status:      database.EventStatusOpen,
+		Closed:      event.Closed,
+		Categories:  event.CategoryIDs,
+		Sources:     make([]sourceResponse, 0, len(event.SourceLinks)),
+		Geometries:  make([]geometryResponse, 0, len(event.Geometries)),
+		UpdatedAt:   event.UpdatedAt.UTC(),
+	}
+	if event.Closed != nil {
+		resp.Status = database.EventStatusClosed
+	}
+	if resp.Categories == nil {
+		resp.Categories = []string{}
+	}
+
+	for _, source := range event.SourceLinks {
+		resp.Sources = append(resp.Sources, sourceResponse{ID: source.SourceID, URL: source.URL})
+	}
+
+	for _, g := range event.Geometries {
+		coordinates := json.RawMessage("null")
+		if g.Coordinates != "" {
+			coordinates = json.RawMessage(g.Coordinates)
+		}
+		resp.Geometries = append(resp.Geometries, geometryResponse{
+			Date:                 g.ObservedAt.UTC(),
+			Type:                 g.Type,
+			Coordinates:          coordinates,
+			MagnitudeValue:       g.MagnitudeValue,
+			MagnitudeUnit:        g.MagnitudeUnit,
+			MagnitudeDescription: g.MagnitudeDescription,
+		})
+	}
+
+	return resp
+}
+
+// listEventsHandler serves GET /api/v1/events
+func (s *Server) listEventsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Ask for one more event than the page holds to know if there is a next page
+	limit := filter.Limit
+	filter.Limit++
+
+	events, err := s.store.ListEvents(r.Context(), filter)
+	if err != nil {
+		s.log(r).WithError(err).Error("Failed to list events")
+		writeError(w, http.StatusInternalServerError, "failed to list events")
+		return
+	}
+
+	page := eventPage{
+		Events: make([]eventResponse, 0, min(len(events), limit)),
+		Limit:  limit,
+		Offset: filter.Offset,
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		next := filter.Offset + limit
+		page.NextOffset = &next
+	}
+	for _, event := range events {
+		page.Events = append(page.Events, newEventResponse(event))
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// exportGeoJSONHandler serves GET /api/v1/events.geojson. It takes the
+// filters of GET /api/v1/events without paging, plus latest=true to export
+// only the most recent position of each event.
+func (s *Server) exportGeoJSONHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := parseEventFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var opts export.Options
+	if v := query.Get("latest"); v != "" {
+		if opts.LatestOnly, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, "latest must be true or false")
+			return
+		}
+	}
+
+	// The export may take longer than the write timeout of the server, which
+	// only bounds each write instead
+	dw := newDeadlineWriter(w, s.config.Server.WriteTimeout)
+	dw.extend()
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+
+	// The status is sent with the first page, a failure later leaves the
+	// collection unterminated so that clients notice
+	features, err := export.WriteGeoJSON(r.Context(), s.store, filter, dw, opts)
+	if err != nil {
+		s.log(r).WithError(err).WithField("features", features).Error("GeoJSON export failed")
+		return
+	}
+
+	s.log(r).WithField("features", features).Debug("Exported events as GeoJSON")
+}
+
+// deadlineWriter pushes the write deadline of a response forward before each
+// write and flush, so that a long response only times out if the client stops
+// reading
+type deadlineWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+// newDeadlineWriter wraps w, a timeout of 0 disables the deadline
+func newDeadlineWriter(w http.ResponseWriter, timeout time.Duration) *deadlineWriter {
+	return &deadlineWriter{w: w, rc: http.NewResponseController(w), timeout: timeout}
+}
+
+// extend moves the write deadline to timeout from now
+func (d *deadlineWriter) extend() {
+	var deadline time.Time
+	if d.timeout > 0 {
+		deadline = time.Now().Add(d.timeout)
+	}
+	// Writers without deadlines, like test recorders, cannot time out
+	_ = d.rc.SetWriteDeadline(deadline)
+}
+
+// Write extends the deadline and writes p
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	d.extend()
+	return d.w.Write(p)
+}
+
+// Flush extends the deadline and sends buffered data to the client
+func (d *deadlineWriter) Flush() {
+	d.extend()
+	_ = d.rc.Flush()
+}
+
+// getEventHandler serves GET /api/v1/events/{id}
+func (s *Server) getEventHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	event, err := s.store.GetEvent(r.Context(), id)
+	if err != nil {
+		s.log(r).WithError(err).WithField(logger.FieldEventID, id).Error("Failed to get event")
+		writeError(w, http.StatusInternalServerError, "failed to get event")
+		return
+	}
+	if event == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("event %s not found", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newEventResponse(event))
+}
+
+// listCategoriesHandler serves GET /api/v1/categories
+func (s *Server) listCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.store.ListCategories(r.Context())
+	if err != nil {
+		s.log(r).WithError(err).Error("Failed to list categories")
+		writeError(w, http.StatusInternalServerError, "failed to list categories")
+		return
+	}
+
+	resp := make([]categoryResponse, 0, len(categories))
+	for _, category := range categories {
+		resp = append(resp, categoryResponse{
+			ID:          category.ID,
+			Title:       category.Title,
+			Link:        category.Link,
+			Description: category.Description,
+			Layers:      category.Layers,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"categories": resp})
+}
+
+// parseEventFilter reads the query parameters of GET /api/v1/events:
+// category, status (open or closed), start and end as RFC 3339 times or
+// dates, bbox as min_lon,min_lat,max_lon,max_lat, limit and offset
+func parseEventFilter(query url.Values) (database.EventFilter, error) {
+	filter := database.EventFilter{
+		CategoryID: query.Get("category"),
+		Limit:      defaultPageSize,
+	}
+
+	switch status := query.Get("status"); status {
+	case "", database.EventStatusOpen, database.EventStatusClosed:
+		filter.Status = status
+	default:
+		return filter, fmt.Errorf("status must be %s or %s", database.EventStatusOpen, database.EventStatusClosed)
+	}
+
+	if v := query.Get("start"); v != "" {
+		start, _, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start: %w", err)
+		}
+		filter.From = &start
+	}
+
+	if v := query.Get("end"); v != "" {
+		end, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end: %w", err)
+		}
+		// An end date includes the whole day
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		filter.To = &end
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("start must be before end")
+	}
+
+	if v := query.Get("bbox"); v != "" {
+		box, err := models.ParseBBox(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid bbox: %w", err)
+		}
+		filter.BBox = &box
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, errors.New("offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam parses an RFC 3339 time or a YYYY-MM-DD date in UTC and
+// reports whether it was a date
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither an RFC 3339 time nor a YYYY-MM-DD date", value)
+	}
+	return t, false, nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
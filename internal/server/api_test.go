@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/pkg/models"
+)
+
+func TestServer_EventsAPI(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	handler := server.Handler()
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 1},
+		{"?category=wildfires&status=open", 1},
+		{"?status=closed", 0},
+		{"?category=volcanoes", 0},
+		{"?start=2025-01-20&end=2025-01-20", 1},
+		{"?start=2025-01-21T00:00:00Z", 0},
+		{"?bbox=-125,35,-115,40", 1},
+		{"?bbox=0,0,10,10", 0},
+		{"?offset=1", 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events"+tt.query, nil))
+
+		var page eventPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/v1/events%s = %d %s", tt.query, rec.Code, rec.Body.String())
+		}
+		if len(page.Events) != tt.want {
+			t.Errorf("GET /api/v1/events%s returned %d events, want %d", tt.query, len(page.Events), tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/EONET_1", nil))
+	var event eventResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &event); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/events/EONET_1 = %d %s", rec.Code, rec.Body.String())
+	}
+	if event.Status != "open" || len(event.Categories) != 1 || len(event.Geometries) != 1 || string(event.Geometries[0].Coordinates) != "[-120,38]" {
+		t.Errorf("GET /api/v1/events/EONET_1 = %+v", event)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/EONET_404", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/events/EONET_404 = %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil))
+	var categories struct {
+		Categories []categoryResponse `json:"categories"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &categories); err != nil || len(categories.Categories) != 1 || categories.Categories[0].ID != "wildfires" {
+		t.Errorf("GET /api/v1/categories = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_ExportGeoJSON(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	handler := server.Handler()
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events.geojson?category=wildfires&latest=true", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/geo+json" {
+		t.Fatalf("GET /api/v1/events.geojson = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			ID         string         `json:"id"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &collection); err != nil || len(collection.Features) != 1 {
+		t.Fatalf("GET /api/v1/events.geojson = %s", rec.Body.String())
+	}
+	if collection.Features[0].ID != "EONET_1/0" || collection.Features[0].Properties["title"] != "Wildfire A" {
+		t.Errorf("feature = %+v", collection.Features[0])
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events.geojson?latest=maybe", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/v1/events.geojson?latest=maybe = %d, want 400", rec.Code)
+	}
+}
+
+// slowQueryStore delays every page of events
+type slowQueryStore struct {
+	database.QueryStore
+	delay time.Duration
+}
+
+func (s slowQueryStore) ListEvents(ctx context.Context, filter database.EventFilter) ([]*models.EventRecord, error) {
+	time.Sleep(s.delay)
+	return s.QueryStore.ListEvents(ctx, filter)
+}
+
+func TestServer_ExportGeoJSONOutlastsWriteTimeout(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Reading the store takes longer than the write timeout
+	server.config.Server.WriteTimeout = 50 * time.Millisecond
+	server.store = slowQueryStore{QueryStore: server.store, delay: 150 * time.Millisecond}
+
+	ts := httptest.NewUnstartedServer(server.Handler())
+	ts.Config.WriteTimeout = server.config.Server.WriteTimeout
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/events.geojson")
+	if err != nil {
+		t.Fatalf("GET /api/v1/events.geojson error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the export error = %v", err)
+	}
+	var collection struct {
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.Unmarshal(body, &collection); err != nil || len(collection.Features) != 1 {
+		t.Errorf("GET /api/v1/events.geojson = %q, want a complete collection", body)
+	}
+}
+
+func TestParseEventFilter_Invalid(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	handler := server.Handler()
+
+	for _, query := range []string{
+		"status=pending",
+		"start=yesterday",
+		"start=2025-01-02&end=2025-01-01",
+		"bbox=1,2,3",
+		"bbox=10,0,0,10",
+		"bbox=-200,0,0,10",
+		"limit=0",
+		"limit=5000",
+		"offset=-1",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/v1/events?%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestServer_EventsPaging(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?limit=1", nil))
+
+	var page eventPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("GET /api/v1/events?limit=1 = %s", rec.Body.String())
+	}
+	if page.Limit != 1 || len(page.Events) != 1 || page.NextOffset != nil {
+		t.Errorf("page = %+v, want one event and no next page", page)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/etl"
+	"nasa-data-hub-etl/internal/logger"
+)
+
+// Paging limits of GET /api/v1/runs
+const (
+	defaultRunPageSize = 20
+	maxRunPageSize     = 100
+)
+
+// runPage is the response of GET /api/v1/runs
+type runPage struct {
+	Runs       []*database.ETLRunInfo `json:"runs"`
+	Limit      int                    `json:"limit"`
+	Offset     int                    `json:"offset"`
+	NextOffset *int                   `json:"next_offset,omitempty"` // Set if there are older runs
+}
+
+// triggerResponse is the response of POST /api/v1/runs
+type triggerResponse struct {
+	RunID  int64  `json:"run_id"`
+	Status string `json:"status"`
+}
+
+// triggerRunHandler serves POST /api/v1/runs on the admin port. The optional
+// JSON body overrides the extraction window, status and category of the run.
+func (s *Server) triggerRunHandler(w http.ResponseWriter, r *http.Request) {
+	var opts etl.RunOptions
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid run options: %v", err))
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The run outlives the request, it is cancelled on shutdown instead. Its
+	// log entries carry the id of the request that triggered it.
+	runID, err := s.pipeline.Trigger(logger.WithFields(s.ctx, logger.Fields(r.Context())), opts)
+	switch {
+	case errors.Is(err, etl.ErrRunInProgress):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, etl.ErrNotLeader):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		s.log(r).WithError(err).Error("Failed to trigger ETL run")
+		writeError(w, http.StatusInternalServerError, "failed to trigger ETL run")
+		return
+	}
+
+	s.log(r).WithField(logger.FieldRunID, runID).WithField("options", opts).Info("Triggered ETL run")
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/runs/%d", runID))
+	writeJSON(w, http.StatusAccepted, triggerResponse{RunID: runID, Status: "running"})
+}
+
+// listRunsHandler serves GET /api/v1/runs, most recently started first
+func (s *Server) listRunsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := defaultRunPageSize
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxRunPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxRunPageSize))
+			return
+		}
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		var err error
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+	}
+
+	// Ask for one more run than the page holds to know if there is a next page
+	runs, err := s.runs.ListETLRuns(r.Context(), limit+1, offset)
+	if err != nil {
+		s.log(r).WithError(err).Error("Failed to list ETL runs")
+		writeError(w, http.StatusInternalServerError, "failed to list ETL runs")
+		return
+	}
+
+	page := runPage{Runs: runs, Limit: limit, Offset: offset}
+	if len(runs) > limit {
+		page.Runs = runs[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	if page.Runs == nil {
+		page.Runs = []*database.ETLRunInfo{}
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// getRunHandler serves GET /api/v1/runs/{id}
+func (s *Server) getRunHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "run id must be an integer")
+		return
+	}
+
+	run, err := s.runs.GetETLRun(r.Context(), id)
+	if err != nil {
+		s.log(r).WithError(err).WithField(logger.FieldRunID, id).Error("Failed to get ETL run")
+		writeError(w, http.StatusInternalServerError, "failed to get ETL run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("run %d not found", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_TriggerRun(t *testing.T) {
+	server, pipeline, store := newTestServer(t)
+	server.config.Server.AdminPort = 9090
+	handler := server.AdminHandler()
+
+	// Runs are not started through the public API
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/runs", nil))
+	if rec.Code != http.StatusMethodNotAllowed || pipeline.IsRunning() {
+		t.Fatalf("POST /api/v1/runs on the API = %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/runs", strings.NewReader(`{"days":7,"category":"wildfires"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /api/v1/runs = %d %s, want 202", rec.Code, rec.Body.String())
+	}
+
+	var triggered triggerResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &triggered); err != nil || triggered.RunID != 1 {
+		t.Fatalf("POST /api/v1/runs = %s, want run 1", rec.Body.String())
+	}
+	if loc := rec.Header().Get("Location"); loc != "/api/v1/runs/1" {
+		t.Errorf("Location = %q, want /api/v1/runs/1", loc)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pipeline.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if run, _ := store.GetETLRun(context.Background(), triggered.RunID); run == nil || run.Status != "completed" {
+		t.Errorf("triggered run = %+v, want completed", run)
+	}
+
+	for _, body := range []string{`{"days":-1}`, `{"days":366}`, `{"status":"pending"}`, `{"unknown":1}`, `not json`} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/runs", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /api/v1/runs %s = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestServer_RunHistory(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	handler := server.Handler()
+	for range 3 {
+		if err := pipeline.Run(context.Background()); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/runs?limit=2", nil))
+
+	var page runPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("GET /api/v1/runs = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(page.Runs) != 2 || page.Runs[0].ID != 3 || page.Runs[1].ID != 2 {
+		t.Errorf("runs = %+v, want runs 3 and 2", page.Runs)
+	}
+	if page.NextOffset == nil || *page.NextOffset != 2 {
+		t.Errorf("next_offset = %v, want 2", page.NextOffset)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/runs/1", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"completed"`) {
+		t.Errorf("GET /api/v1/runs/1 = %d %s, want the completed run", rec.Code, rec.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/runs/9":         http.StatusNotFound,
+		"/api/v1/runs/latest":    http.StatusBadRequest,
+		"/api/v1/runs?limit=0":   http.StatusBadRequest,
+		"/api/v1/runs?offset=-1": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
@@ -2,12 +2,18 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"regexp"
 	"time"
 
 	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/database"
 	"nasa-data-hub-etl/internal/etl"
+	"nasa-data-hub-etl/internal/logger"
+	"nasa-data-hub-etl/internal/metrics"
 
 	"github.com/sirupsen/logrus"
 )
@@ -16,8 +22,12 @@ import (
 type Server struct {
 	config   *config.Config
 	pipeline *etl.Pipeline
+	store    database.QueryStore
+	runs     database.RunStore
 	logger   *logrus.Logger
 	server   *http.Server
+	admin    *http.Server    // Nil unless server.admin_port is set
+	ctx      context.Context // Parent of runs triggered through the API
 }
 
 // NewServer creates a new HTTP server
@@ -25,25 +35,110 @@ func NewServer(cfg *config.Config, pipeline *etl.Pipeline, logger *logrus.Logger
 	return &Server{
 		config:   cfg,
 		pipeline: pipeline,
+		store:    pipeline.Store(),
+		runs:     pipeline.Store(),
 		logger:   logger,
+		ctx:      context.Background(),
 	}
 }
 
-// Start starts the HTTP server
-func (s *Server) Start(ctx context.Context) error {
+// Handler returns the HTTP handler serving the public endpoints
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check endpoints
 	mux.HandleFunc("/health", s.healthHandler)
 	mux.HandleFunc("/ready", s.readyHandler)
-	mux.HandleFunc("/metrics", s.metricsHandler)
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	// Read API over the loaded data
+	mux.HandleFunc("GET /api/v1/events", s.listEventsHandler)
+	mux.HandleFunc("GET /api/v1/events.geojson", s.exportGeoJSONHandler)
+	mux.HandleFunc("GET /api/v1/events/{id}", s.getEventHandler)
+	mux.HandleFunc("GET /api/v1/categories", s.listCategoriesHandler)
+
+	// Run history
+	mux.HandleFunc("GET /api/v1/runs", s.listRunsHandler)
+	mux.HandleFunc("GET /api/v1/runs/{id}", s.getRunHandler)
+
+	return withRequestID(mux)
+}
+
+// AdminHandler returns the HTTP handler serving the admin endpoints, or nil
+// if server.admin_port is not set. They change the running process, so they
+// are only served on their own port that is not exposed with the API.
+func (s *Server) AdminHandler() http.Handler {
+	if s.config.Server.AdminPort == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+
+	// On-demand runs
+	mux.HandleFunc("POST /api/v1/runs", s.triggerRunHandler)
+
+	// Runtime administration
+	mux.HandleFunc("GET /admin/log-level", s.getLogLevelHandler)
+	mux.HandleFunc("PUT /admin/log-level", s.setLogLevelHandler)
+
+	return withRequestID(mux)
+}
+
+// requestIDHeader carries the id that correlates a request with its log entries
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID matches the client supplied request ids that are kept
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// withRequestID gives every request an id, taken from the X-Request-ID header
+// if the client sent a sane one. The id is echoed in the response and carried
+// by the request context into log entries.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithField(r.Context(), logger.FieldHTTPRequestID, id)))
+	})
+}
+
+// newRequestID returns a random 128 bit id in hex
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// log returns the logger with the correlation fields of a request
+func (s *Server) log(r *http.Request) *logrus.Entry {
+	return logger.FromContext(r.Context(), s.logger)
+}
 
+// Start starts the HTTP server, and the admin server if server.admin_port is
+// set. It returns when one of them stops.
+func (s *Server) Start(ctx context.Context) error {
+	s.ctx = ctx
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.config.Server.Port),
-		Handler:      mux,
+		Handler:      s.Handler(),
 		ReadTimeout:  s.config.Server.ReadTimeout,
 		WriteTimeout: s.config.Server.WriteTimeout,
 	}
+	servers := []*http.Server{s.server}
+
+	if handler := s.AdminHandler(); handler != nil {
+		s.admin = &http.Server{
+			Addr:         fmt.Sprintf(":%d", s.config.Server.AdminPort),
+			Handler:      handler,
+			ReadTimeout:  s.config.Server.ReadTimeout,
+			WriteTimeout: s.config.Server.WriteTimeout,
+		}
+		servers = append(servers, s.admin)
+		s.logger.WithField("port", s.config.Server.AdminPort).Info("Starting admin HTTP server")
+	}
 
 	s.logger.WithField("port", s.config.Server.Port).Info("Starting HTTP server")
 
@@ -54,12 +149,20 @@ func (s *Server) Start(ctx context.Context) error {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		if err := s.server.Shutdown(shutdownCtx); err != nil {
-			s.logger.WithError(err).Error("Failed to shutdown HTTP server gracefully")
+		for _, server := range servers {
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				s.logger.WithError(err).WithField("addr", server.Addr).Error("Failed to shutdown HTTP server gracefully")
+			}
 		}
 	}()
 
-	return s.server.ListenAndServe()
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			errs <- server.ListenAndServe()
+		}()
+	}
+	return <-errs
 }
 
 // healthHandler handles health check requests
@@ -74,7 +177,7 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := s.pipeline.HealthCheck(ctx); err != nil {
-		s.logger.WithError(err).Error("Health check failed")
+		s.log(r).WithError(err).Error("Health check failed")
 		http.Error(w, "Health check failed", http.StatusServiceUnavailable)
 		return
 	}
@@ -91,58 +194,17 @@ func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// For now, readiness is the same as health
-	// In the future, this could check if the application is ready to process requests
+	// Standby instances are ready too, leader reports which one runs the pipeline
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	if err := s.pipeline.HealthCheck(ctx); err != nil {
-		s.logger.WithError(err).Error("Readiness check failed")
+		s.log(r).WithError(err).Error("Readiness check failed")
 		http.Error(w, "Not ready", http.StatusServiceUnavailable)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"status":"ready","timestamp":"%s"}`, time.Now().UTC().Format(time.RFC3339))
-}
-
-// metricsHandler handles metrics requests
-func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// For now, return basic metrics
-	// In the future, this could integrate with Prometheus
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusOK)
-
-	// Get last run info
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	lastRun, err := s.pipeline.GetLastRunInfo(ctx)
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to get last run info")
-		fmt.Fprintf(w, "# Error getting metrics: %v\n", err)
-		return
-	}
-
-	if lastRun != nil {
-		fmt.Fprintf(w, "# HELP etl_runs_total Total number of ETL runs\n")
-		fmt.Fprintf(w, "# TYPE etl_runs_total counter\n")
-		fmt.Fprintf(w, "etl_runs_total{status=\"%s\"} 1\n", lastRun.Status)
-
-		fmt.Fprintf(w, "# HELP etl_events_processed_total Total events processed\n")
-		fmt.Fprintf(w, "# TYPE etl_events_processed_total counter\n")
-		fmt.Fprintf(w, "etl_events_processed_total %d\n", lastRun.EventsProcessed)
-
-		fmt.Fprintf(w, "# HELP etl_categories_processed_total Total categories processed\n")
-		fmt.Fprintf(w, "# TYPE etl_categories_processed_total counter\n")
-		fmt.Fprintf(w, "etl_categories_processed_total %d\n", lastRun.CategoriesProcessed)
-	} else {
-		fmt.Fprintf(w, "# No ETL runs found\n")
-	}
+	fmt.Fprintf(w, `{"status":"ready","leader":%t,"timestamp":"%s"}`, s.pipeline.IsLeader(), time.Now().UTC().Format(time.RFC3339))
 }
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nasa-data-hub-etl/internal/config"
+	"nasa-data-hub-etl/internal/database"
+	"nasa-data-hub-etl/internal/etl"
+	"nasa-data-hub-etl/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestServer returns a server whose pipeline reads from a fake EONET API
+// and writes to an in-memory store
+func newTestServer(t *testing.T) (*Server, *etl.Pipeline, *database.MemoryStore) {
+	t.Helper()
+
+	eonet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/categories":
+			fmt.Fprint(w, `{"categories":[{"id":"wildfires","title":"Wildfires"}]}`)
+		case "/events":
+			fmt.Fprint(w, `{"events":[{"id":"EONET_1","title":"Wildfire A","categories":[{"id":"wildfires"}],`+
+				`"geometry":[{"date":"2025-01-20T00:00:00Z","type":"Point","coordinates":[-120,38]}]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(eonet.Close)
+
+	cfg := &config.Config{
+		NASA: config.NASAConfig{APIURL: eonet.URL},
+		ETL:  config.ETLConfig{BatchSize: 100, InitialLookback: 24 * time.Hour},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	store := database.NewMemoryStore()
+	pipeline := etl.NewPipelineWithStore(cfg, store, logger)
+	return NewServer(cfg, pipeline, logger), pipeline, store
+}
+
+func TestServer_Health(t *testing.T) {
+	server, _, store := newTestServer(t)
+	handler := server.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"healthy"`) {
+		t.Errorf("GET /health = %d %s, want 200 healthy", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/health", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /health = %d, want 405", rec.Code)
+	}
+
+	// Without leader election every instance runs the pipeline
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"leader":true`) {
+		t.Errorf("GET /ready = %d %s, want 200 leader", rec.Code, rec.Body.String())
+	}
+
+	// A closed store fails the health check
+	store.Close()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /ready with closed store = %d, want 503", rec.Code)
+	}
+}
+
+func TestServer_MetricsAfterRun(t *testing.T) {
+	server, pipeline, _ := newTestServer(t)
+	handler := server.Handler()
+
+	// Collectors are process wide, other tests run the pipeline too
+	completed := testutil.ToFloat64(metrics.RunsTotal.WithLabelValues("completed"))
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.RunsTotal.WithLabelValues("completed")); got != completed+1 {
+		t.Errorf("etl_runs_total{status=\"completed\"} = %v, want %v", got, completed+1)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`etl_runs_total{status="completed"}`,
+		`etl_events_loaded_total{result="inserted"}`,
+		`eonet_api_requests_total{code="200",endpoint="events"}`,
+		`eonet_api_request_duration_seconds_bucket{endpoint="categories"`,
+		`etl_db_batch_duration_seconds_count{operation="upsert_events"}`,
+		"etl_run_duration_seconds_count",
+		"etl_last_success_timestamp_seconds",
+		"etl_open_events 1",
+		"etl_runs_abandoned_total",
+		"etl_leader 1",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /metrics misses %s", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /metrics = %d, want 405", rec.Code)
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	handler := server.Handler()
+
+	// A sane client id is echoed back
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(requestIDHeader, "client-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got != "client-42" {
+		t.Errorf("X-Request-ID = %q, want client-42", got)
+	}
+
+	// Missing and malformed ids are replaced by a generated one
+	for _, id := range []string{"", "bad id\n", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set(requestIDHeader, id)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get(requestIDHeader); len(got) != 32 || got == id {
+			t.Errorf("X-Request-ID for %q = %q, want a generated 32 character id", id, got)
+		}
+	}
+}
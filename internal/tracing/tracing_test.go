@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nasa-data-hub-etl/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_FileExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TraceExporterFile, FilePath: path, SampleRatio: 1}, logger)
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	_, span := Start(context.Background(), "test.span")
+	End(span, errors.New("boom"))
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read traces: %v", err)
+	}
+	for _, want := range []string{`"Name":"test.span"`, `"Code":"Error"`, `"Value":"nasa-data-hub-etl"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("exported trace misses %s: %s", want, data)
+		}
+	}
+}
+
+func TestSetup_None(t *testing.T) {
+	previous := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TraceExporterNone}, logrus.New())
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("Setup() with the none exporter replaced the tracer provider")
+	}
+}
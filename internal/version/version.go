@@ -0,0 +1,77 @@
+// Package version reports the version and build information of the binary
+package version
+
+import (
+	"runtime/debug"
+)
+
+// Set at build time with
+//
+//	-ldflags "-X nasa-data-hub-etl/internal/version.Version=1.2.3 -X nasa-data-hub-etl/internal/version.GitCommit=$(git rev-parse HEAD)"
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the binary. Values not set with
+// -ldflags are taken from the VCS stamp Go embeds in module builds.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = build.GoVersion
+
+	var revision string
+	var modified bool
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	if info.GitCommit == "" && revision != "" {
+		info.GitCommit = revision
+		if modified {
+			info.GitCommit += "-dirty"
+		}
+	}
+
+	return info
+}
+
+// String returns a one-line description such as "1.2.3 (abc1234)"
+func (i Info) String() string {
+	if i.GitCommit == "" {
+		return i.Version
+	}
+
+	commit := i.GitCommit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return i.Version + " (" + commit + ")"
+}
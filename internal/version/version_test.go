@@ -0,0 +1,29 @@
+package version
+
+import "testing"
+
+func TestInfo_String(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Version: "dev"}, "dev"},
+		{Info{Version: "1.2.3", GitCommit: "abc1234"}, "1.2.3 (abc1234)"},
+		{Info{Version: "1.2.3", GitCommit: "0123456789abcdef0123"}, "1.2.3 (0123456789ab)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestGet_PrefersLinkerFlags(t *testing.T) {
+	defer func(v, c string) { Version, GitCommit = v, c }(Version, GitCommit)
+	Version, GitCommit = "1.2.3", "abc1234"
+
+	if info := Get(); info.Version != "1.2.3" || info.GitCommit != "abc1234" {
+		t.Errorf("Get() = %+v", info)
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 )
@@ -12,6 +14,43 @@ type EONETResponse struct {
 	Link        string     `json:"link"`
 	Events      []Event    `json:"events"`
 	Categories  []Category `json:"categories"`
+
+	// InvalidEvents holds the decoding errors of events that were left out of
+	// Events, e.g. because of an unclosed polygon ring
+	InvalidEvents []error `json:"-"`
+}
+
+// UnmarshalJSON decodes events one at a time so that a single malformed event
+// doesn't fail the whole response
+func (r *EONETResponse) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Title       string            `json:"title"`
+		Description string            `json:"description"`
+		Link        string            `json:"link"`
+		Events      []json.RawMessage `json:"events"`
+		Categories  []Category        `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.Title = raw.Title
+	r.Description = raw.Description
+	r.Link = raw.Link
+	r.Categories = raw.Categories
+	r.Events = make([]Event, 0, len(raw.Events))
+	r.InvalidEvents = nil
+
+	for i, data := range raw.Events {
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			r.InvalidEvents = append(r.InvalidEvents, newInvalidEventError(i, data, err))
+			continue
+		}
+		r.Events = append(r.Events, event)
+	}
+
+	return nil
 }
 
 // Event represents a natural event from EONET
@@ -24,6 +63,121 @@ type Event struct {
 	Sources     []Source         `json:"sources"`
 	Geometry    []Geometry       `json:"geometry"`
 	Closed      *string          `json:"closed"`
+
+	// InvalidGeometries holds the decoding errors of observations that were
+	// left out of Geometry, e.g. because of an out of range coordinate
+	InvalidGeometries []error `json:"-"`
+}
+
+// InvalidGeometryError is the decoding error of an observation left out of
+// an event
+type InvalidGeometryError struct {
+	Index int // Position of the observation in the event
+	Err   error
+}
+
+// Error implements the error interface
+func (e *InvalidGeometryError) Error() string {
+	return fmt.Sprintf("geometry %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the decoding error
+func (e *InvalidGeometryError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalJSON decodes the observations one at a time so that a single bad
+// observation only drops itself. The event fails to decode only if it has
+// observations and none of them is valid.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type event Event
+	var raw struct {
+		event
+		Geometry []json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Event(raw.event)
+	e.Geometry = nil
+	e.InvalidGeometries = nil
+	if raw.Geometry != nil {
+		e.Geometry = make([]Geometry, 0, len(raw.Geometry))
+	}
+
+	for i, data := range raw.Geometry {
+		var geometry Geometry
+		if err := json.Unmarshal(data, &geometry); err != nil {
+			e.InvalidGeometries = append(e.InvalidGeometries, &InvalidGeometryError{Index: i, Err: err})
+			continue
+		}
+		e.Geometry = append(e.Geometry, geometry)
+	}
+
+	if len(e.Geometry) == 0 && len(e.InvalidGeometries) > 0 {
+		return fmt.Errorf("none of %d geometries is valid, first: %w", len(e.InvalidGeometries), e.InvalidGeometries[0])
+	}
+	return nil
+}
+
+// LatestActivity returns the most recent time the event changed, i.e. the
+// latest geometry observation or the closing date. It returns the zero time
+// if the event carries neither.
+func (e *Event) LatestActivity() time.Time {
+	var latest time.Time
+	for _, geometry := range e.Geometry {
+		if geometry.Date.After(latest) {
+			latest = geometry.Date
+		}
+	}
+
+	if e.Closed != nil {
+		if closed, err := time.Parse(time.RFC3339, *e.Closed); err == nil && closed.After(latest) {
+			latest = closed
+		}
+	}
+
+	return latest
+}
+
+// FirstObservation returns the earliest geometry of the event, or nil if it has none
+func (e *Event) FirstObservation() *Geometry {
+	var first *Geometry
+	for i := range e.Geometry {
+		if first == nil || e.Geometry[i].Date.Before(first.Date) {
+			first = &e.Geometry[i]
+		}
+	}
+	return first
+}
+
+// LastObservation returns the latest geometry of the event, or nil if it has none
+func (e *Event) LastObservation() *Geometry {
+	var last *Geometry
+	for i := range e.Geometry {
+		if last == nil || !e.Geometry[i].Date.Before(last.Date) {
+			last = &e.Geometry[i]
+		}
+	}
+	return last
+}
+
+// BBox returns the bounding box of all geometries of the event, ok is false
+// if it has none
+func (e *Event) BBox() (box BBox, ok bool) {
+	for i := range e.Geometry {
+		geometryBox, hasBox := e.Geometry[i].BBox()
+		if !hasBox {
+			continue
+		}
+		if !ok {
+			box, ok = geometryBox, true
+			continue
+		}
+		box = box.Extend(geometryBox)
+	}
+	return box, ok
 }
 
 // CategoryObject represents a category object in an event
@@ -32,7 +186,15 @@ type CategoryObject struct {
 	Title string      `json:"title"`
 }
 
-// GetIDAsInt converts the ID to int, handling both string and int types
+// GetID returns the category identifier as a string. EONET v3 uses string
+// identifiers such as "wildfires", older API versions used numbers.
+func (co *CategoryObject) GetID() string {
+	return categoryIDString(co.ID)
+}
+
+// GetIDAsInt converts the ID to int, handling both string and int types.
+//
+// Deprecated: EONET v3 identifiers are not numeric and collapse to 0, use GetID.
 func (co *CategoryObject) GetIDAsInt() int {
 	switch v := co.ID.(type) {
 	case int:
@@ -59,7 +221,14 @@ type Category struct {
 	Layers      string      `json:"layers"`
 }
 
-// GetIDAsInt converts the ID to int, handling both string and int types
+// GetID returns the category identifier as a string
+func (c *Category) GetID() string {
+	return categoryIDString(c.ID)
+}
+
+// GetIDAsInt converts the ID to int, handling both string and int types.
+//
+// Deprecated: EONET v3 identifiers are not numeric and collapse to 0, use GetID.
 func (c *Category) GetIDAsInt() int {
 	switch v := c.ID.(type) {
 	case int:
@@ -77,6 +246,20 @@ func (c *Category) GetIDAsInt() int {
 	}
 }
 
+// categoryIDString normalizes a category identifier decoded from JSON to a string
+func categoryIDString(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
 // Source represents a data source for an event
 type Source struct {
 	ID    string `json:"id"`
@@ -84,30 +267,60 @@ type Source struct {
 	Title string `json:"title"`
 }
 
-// Geometry represents the geographic data for an event
-type Geometry struct {
-	Date        time.Time   `json:"date"`
-	Type        string      `json:"type"`
-	Coordinates interface{} `json:"coordinates"`
-}
-
 // EventRecord represents a processed event record for database storage
 type EventRecord struct {
 	ID          string    `db:"id"`
 	Title       string    `db:"title"`
 	Description string    `db:"description"`
 	Link        string    `db:"link"`
-	Categories  string    `db:"categories"` // JSON string
+	Categories  string    `db:"categories"` // JSON array of category ids
 	Sources     string    `db:"sources"`    // JSON string
 	Geometry    string    `db:"geometry"`   // JSON string
 	Closed      *string   `db:"closed"`
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
+
+	// Normalized child rows, written to their own tables with the event
+	CategoryIDs []string              `db:"-"`
+	SourceLinks []EventSourceRecord   `db:"-"`
+	Geometries  []EventGeometryRecord `db:"-"`
+}
+
+// EventGeometryRecord represents one geometry observation of an event
+type EventGeometryRecord struct {
+	EventID              string    `db:"event_id"`
+	Seq                  int       `db:"seq"`
+	ObservedAt           time.Time `db:"observed_at"`
+	Type                 string    `db:"geometry_type"`
+	Longitude            *float64  `db:"longitude"`
+	Latitude             *float64  `db:"latitude"`
+	MagnitudeValue       *float64  `db:"magnitude_value"`
+	MagnitudeUnit        *string   `db:"magnitude_unit"`
+	MagnitudeDescription *string   `db:"magnitude_description"`
+	Coordinates          string    `db:"coordinates"` // GeoJSON coordinates
+}
+
+// BBox decodes the coordinates and returns their bounding box, ok is false if
+// they are missing or invalid
+func (r *EventGeometryRecord) BBox() (box BBox, ok bool) {
+	var geometry Geometry
+	data := fmt.Sprintf(`{"type":%q,"coordinates":%s}`, r.Type, r.Coordinates)
+	if r.Coordinates == "" || json.Unmarshal([]byte(data), &geometry) != nil {
+		return BBox{}, false
+	}
+	return geometry.BBox()
+}
+
+// EventSourceRecord links an event to one of its data sources
+type EventSourceRecord struct {
+	EventID  string `db:"event_id"`
+	SourceID string `db:"source_id"`
+	URL      string `db:"url"`
 }
 
 // CategoryRecord represents a category record for database storage
 type CategoryRecord struct {
-	ID          int       `db:"id"`
+	ID          string    `db:"id"`
 	Title       string    `db:"title"`
 	Link        string    `db:"link"`
 	Description string    `db:"description"`
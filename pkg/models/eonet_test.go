@@ -121,6 +121,129 @@ func TestCategoryObject_GetIDAsInt(t *testing.T) {
 	}
 }
 
+func TestCategory_GetID(t *testing.T) {
+	tests := []struct {
+		name     string
+		category Category
+		expected string
+	}{
+		{
+			name:     "v3 string ID",
+			category: Category{ID: "wildfires"},
+			expected: "wildfires",
+		},
+		{
+			name:     "camel case string ID",
+			category: Category{ID: "severeStorms"},
+			expected: "severeStorms",
+		},
+		{
+			name:     "int ID",
+			category: Category{ID: 8},
+			expected: "8",
+		},
+		{
+			name:     "float64 ID",
+			category: Category{ID: 8.0},
+			expected: "8",
+		},
+		{
+			name:     "nil ID",
+			category: Category{ID: nil},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.category.GetID(); result != tt.expected {
+				t.Errorf("GetID() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCategoryObject_GetID(t *testing.T) {
+	tests := []struct {
+		name     string
+		category CategoryObject
+		expected string
+	}{
+		{
+			name:     "v3 string ID",
+			category: CategoryObject{ID: "seaLakeIce"},
+			expected: "seaLakeIce",
+		},
+		{
+			name:     "float64 ID",
+			category: CategoryObject{ID: 12.0},
+			expected: "12",
+		},
+		{
+			name:     "nil ID",
+			category: CategoryObject{ID: nil},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.category.GetID(); result != tt.expected {
+				t.Errorf("GetID() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvent_LatestActivity(t *testing.T) {
+	closed := "2025-01-25T00:00:00Z"
+	invalidClosed := "not a date"
+
+	tests := []struct {
+		name     string
+		event    Event
+		expected time.Time
+	}{
+		{
+			name: "latest geometry",
+			event: Event{Geometry: []Geometry{
+				{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+				{Date: time.Date(2025, 1, 22, 0, 0, 0, 0, time.UTC)},
+			}},
+			expected: time.Date(2025, 1, 22, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "closed after last geometry",
+			event: Event{
+				Geometry: []Geometry{{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)}},
+				Closed:   &closed,
+			},
+			expected: time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "invalid closed date is ignored",
+			event: Event{
+				Geometry: []Geometry{{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)}},
+				Closed:   &invalidClosed,
+			},
+			expected: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "no activity",
+			event:    Event{},
+			expected: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.event.LatestActivity(); !result.Equal(tt.expected) {
+				t.Errorf("LatestActivity() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEventRecord_Validation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -134,7 +257,7 @@ func TestEventRecord_Validation(t *testing.T) {
 				Title:       "Test Event",
 				Description: "Test Description",
 				Link:        "https://example.com",
-				Categories:  `[8, 12]`,
+				Categories:  `["wildfires","volcanoes"]`,
 				Sources:     `[{"id":"test","url":"https://test.com","title":"Test Source"}]`,
 				Geometry:    `[{"date":"2025-01-22T10:30:00Z","type":"Point","coordinates":[-120.5,37.8]}]`,
 				CreatedAt:   time.Now(),
@@ -185,7 +308,7 @@ func TestCategoryRecord_Validation(t *testing.T) {
 		{
 			name: "valid record",
 			record: CategoryRecord{
-				ID:          8,
+				ID:          "wildfires",
 				Title:       "Wildfires",
 				Link:        "https://example.com",
 				Description: "Wildfire events",
@@ -196,9 +319,9 @@ func TestCategoryRecord_Validation(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "zero ID",
+			name: "empty ID",
 			record: CategoryRecord{
-				ID:        0,
+				ID:        "",
 				Title:     "Wildfires",
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
@@ -208,7 +331,7 @@ func TestCategoryRecord_Validation(t *testing.T) {
 		{
 			name: "empty title",
 			record: CategoryRecord{
-				ID:        8,
+				ID:        "wildfires",
 				Title:     "",
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
@@ -239,8 +362,8 @@ func validateEventRecord(record EventRecord) error {
 }
 
 func validateCategoryRecord(record CategoryRecord) error {
-	if record.ID == 0 {
-		return &ValidationError{Field: "ID", Message: "ID cannot be zero"}
+	if record.ID == "" {
+		return &ValidationError{Field: "ID", Message: "ID cannot be empty"}
 	}
 	if record.Title == "" {
 		return &ValidationError{Field: "Title", Message: "Title cannot be empty"}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EventDecoder reads the events of an EONET events response one at a time
+// while the response is read, so that only the current event is held in
+// memory however many events the response holds
+type EventDecoder struct {
+	dec     *json.Decoder
+	started bool // The events array has been entered
+	done    bool
+	index   int
+}
+
+// NewEventDecoder returns a decoder of the events response read from r
+func NewEventDecoder(r io.Reader) *EventDecoder {
+	return &EventDecoder{dec: json.NewDecoder(r)}
+}
+
+// InvalidEventError is returned by EventDecoder.Next for an event that is
+// left out, e.g. because of an unclosed polygon ring
+type InvalidEventError struct {
+	Index int    // Position of the event in the response
+	ID    string // Empty if the id could not be read
+	Err   error
+}
+
+// Error implements the error interface
+func (e *InvalidEventError) Error() string {
+	return fmt.Sprintf("event %d (%s): %v", e.Index, e.ID, e.Err)
+}
+
+// Unwrap returns the decoding error
+func (e *InvalidEventError) Unwrap() error {
+	return e.Err
+}
+
+// newInvalidEventError returns the error of the event at index that failed
+// to decode from data
+func newInvalidEventError(index int, data []byte, err error) *InvalidEventError {
+	// Keep the id for the error message if it can be read
+	var id struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(data, &id)
+	return &InvalidEventError{Index: index, ID: id.ID, Err: err}
+}
+
+// Next returns the next event of the response. It returns io.EOF after the
+// last event. After an *InvalidEventError decoding continues with the next
+// event, any other error ends the response.
+func (d *EventDecoder) Next() (Event, error) {
+	if d.done {
+		return Event{}, io.EOF
+	}
+
+	if !d.started {
+		if err := d.findEvents(); err != nil {
+			d.done = true
+			return Event{}, err
+		}
+		d.started = true
+	}
+
+	if !d.dec.More() {
+		d.done = true
+		if _, err := d.dec.Token(); err != nil {
+			return Event{}, fmt.Errorf("failed to read end of events: %w", unexpectedEOF(err))
+		}
+		return Event{}, io.EOF
+	}
+
+	var data json.RawMessage
+	if err := d.dec.Decode(&data); err != nil {
+		d.done = true
+		return Event{}, fmt.Errorf("failed to read event %d: %w", d.index, unexpectedEOF(err))
+	}
+
+	index := d.index
+	d.index++
+
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return Event{}, newInvalidEventError(index, data, err)
+	}
+	return event, nil
+}
+
+// findEvents reads the response up to the first event. It returns io.EOF if
+// the response has no events.
+func (d *EventDecoder) findEvents() error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", unexpectedEOF(err))
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("response is not a JSON object")
+	}
+
+	for d.dec.More() {
+		key, err := d.dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", unexpectedEOF(err))
+		}
+
+		if key != "events" {
+			// Other fields such as the title are small, skip them whole
+			var skip json.RawMessage
+			if err := d.dec.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to read response field %v: %w", key, unexpectedEOF(err))
+			}
+			continue
+		}
+
+		token, err := d.dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read events: %w", unexpectedEOF(err))
+		}
+		switch token {
+		case json.Delim('['):
+			return nil
+		case nil:
+			return io.EOF
+		default:
+			return fmt.Errorf("events is not a JSON array")
+		}
+	}
+
+	return io.EOF
+}
+
+// unexpectedEOF turns the end of input inside the response into an error,
+// so that it is not mistaken for the end of the events
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
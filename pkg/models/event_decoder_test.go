@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// decodeAll reads every event of data, collecting the invalid ones apart
+func decodeAll(t *testing.T, data string) (ids []string, invalid []*InvalidEventError, err error) {
+	t.Helper()
+
+	decoder := NewEventDecoder(strings.NewReader(data))
+	for {
+		event, err := decoder.Next()
+		var invalidErr *InvalidEventError
+		switch {
+		case errors.Is(err, io.EOF):
+			return ids, invalid, nil
+		case errors.As(err, &invalidErr):
+			invalid = append(invalid, invalidErr)
+		case err != nil:
+			return ids, invalid, err
+		default:
+			ids = append(ids, event.ID)
+		}
+	}
+}
+
+func TestEventDecoder(t *testing.T) {
+	data := `{"title":"EONET Events","link":"https://eonet.example/api/v3/events","events":[
+		{"id":"EONET_1","geometry":[{"type":"Point","coordinates":[1,2]}]},
+		{"id":"EONET_2","geometry":[{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1]]]}]},
+		{"id":"EONET_3","geometry":[]}
+	],"description":"after the events"}`
+
+	ids, invalid, err := decodeAll(t, data)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if strings.Join(ids, ",") != "EONET_1,EONET_3" {
+		t.Errorf("events = %v, want EONET_1 and EONET_3", ids)
+	}
+	if len(invalid) != 1 || invalid[0].Index != 1 || invalid[0].ID != "EONET_2" {
+		t.Errorf("invalid events = %v, want EONET_2 at index 1", invalid)
+	}
+}
+
+func TestEventDecoder_NoEvents(t *testing.T) {
+	for _, data := range []string{`{"title":"EONET Events"}`, `{"events":[]}`, `{"events":null}`} {
+		ids, _, err := decodeAll(t, data)
+		if err != nil || len(ids) != 0 {
+			t.Errorf("decoding %s = %v, %v, want no events", data, ids, err)
+		}
+	}
+}
+
+func TestEventDecoder_Malformed(t *testing.T) {
+	for _, data := range []string{
+		``,
+		`[]`,
+		`{"events":{}}`,
+		`{"events":[{"id":"EONET_1","geometry":[]}`,
+		`{"events":[{"id":"EONET_1","geometry":[]},{"id":"EON`,
+	} {
+		if _, _, err := decodeAll(t, data); err == nil {
+			t.Errorf("decoding %q expected error", data)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GeoJSON geometry types emitted by EONET
+const (
+	GeometryPoint        = "Point"
+	GeometryPolygon      = "Polygon"
+	GeometryMultiPolygon = "MultiPolygon"
+)
+
+// Geometry represents one dated observation of an event's location
+type Geometry struct {
+	Date                 time.Time   `json:"date"`
+	Type                 string      `json:"type"`
+	Coordinates          Coordinates `json:"coordinates"`                    // Point, Polygon or MultiPolygon
+	MagnitudeValue       *float64    `json:"magnitudeValue,omitempty"`       // e.g. 65 for a storm
+	MagnitudeUnit        *string     `json:"magnitudeUnit,omitempty"`        // e.g. "kts" or "acres"
+	MagnitudeDescription *string     `json:"magnitudeDescription,omitempty"` // e.g. "Maximum Sustained Wind Speed"
+}
+
+// UnmarshalJSON decodes the coordinates into the concrete type named by the
+// geometry type and validates them
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Date                 time.Time       `json:"date"`
+		Type                 string          `json:"type"`
+		Coordinates          json.RawMessage `json:"coordinates"`
+		MagnitudeValue       *float64        `json:"magnitudeValue"`
+		MagnitudeUnit        *string         `json:"magnitudeUnit"`
+		MagnitudeDescription *string         `json:"magnitudeDescription"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var coordinates Coordinates
+	switch raw.Type {
+	case GeometryPoint:
+		coordinates = &Point{}
+	case GeometryPolygon:
+		coordinates = &Polygon{}
+	case GeometryMultiPolygon:
+		coordinates = &MultiPolygon{}
+	default:
+		return fmt.Errorf("unsupported geometry type %q", raw.Type)
+	}
+
+	if err := json.Unmarshal(raw.Coordinates, coordinates); err != nil {
+		return fmt.Errorf("invalid %s coordinates: %w", raw.Type, err)
+	}
+	if err := coordinates.Validate(); err != nil {
+		return fmt.Errorf("invalid %s coordinates: %w", raw.Type, err)
+	}
+
+	// Store values rather than pointers so callers can type switch on them
+	switch c := coordinates.(type) {
+	case *Point:
+		g.Coordinates = *c
+	case *Polygon:
+		g.Coordinates = *c
+	case *MultiPolygon:
+		g.Coordinates = *c
+	}
+	g.Date = raw.Date
+	g.Type = raw.Type
+	g.MagnitudeValue = raw.MagnitudeValue
+	g.MagnitudeUnit = raw.MagnitudeUnit
+	g.MagnitudeDescription = raw.MagnitudeDescription
+	return nil
+}
+
+// BBox returns the bounding box of the geometry, ok is false if it has no coordinates
+func (g *Geometry) BBox() (box BBox, ok bool) {
+	if g.Coordinates == nil {
+		return BBox{}, false
+	}
+	return g.Coordinates.BBox(), true
+}
+
+// Centroid returns the centroid of the geometry, ok is false if it has no coordinates
+func (g *Geometry) Centroid() (centroid Position, ok bool) {
+	if g.Coordinates == nil {
+		return Position{}, false
+	}
+	return g.Coordinates.Centroid(), true
+}
+
+// Coordinates is implemented by Point, Polygon and MultiPolygon
+type Coordinates interface {
+	// Validate checks coordinate ranges and ring closure
+	Validate() error
+	// BBox returns the bounding box of all positions
+	BBox() BBox
+	// Centroid returns the center of mass
+	Centroid() Position
+}
+
+// Position is a longitude/latitude pair, encoded as [lon, lat] in GeoJSON
+type Position struct {
+	Lon float64
+	Lat float64
+}
+
+// UnmarshalJSON decodes [lon, lat] and ignores an optional altitude
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if len(values) < 2 {
+		return fmt.Errorf("position needs longitude and latitude, got %d values", len(values))
+	}
+	p.Lon, p.Lat = values[0], values[1]
+	return nil
+}
+
+// MarshalJSON encodes the position as [lon, lat]
+func (p Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{p.Lon, p.Lat})
+}
+
+// Validate checks that the position lies within WGS 84 bounds
+func (p Position) Validate() error {
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lon)
+	}
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat)
+	}
+	return nil
+}
+
+// BBox is a bounding box in longitude/latitude
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// ParseBBox parses a box written as min_lon,min_lat,max_lon,max_lat
+func ParseBBox(value string) (BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BBox{}, errors.New("want min_lon,min_lat,max_lon,max_lat")
+	}
+
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, fmt.Errorf("%q is not a number", part)
+		}
+		v[i] = f
+	}
+
+	box := BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if box.MinLon < -180 || box.MaxLon > 180 || box.MinLat < -90 || box.MaxLat > 90 {
+		return BBox{}, errors.New("coordinates out of range")
+	}
+	if box.MinLon > box.MaxLon || box.MinLat > box.MaxLat {
+		return BBox{}, errors.New("minimum greater than maximum")
+	}
+	return box, nil
+}
+
+// Extend returns the smallest box containing both boxes
+func (b BBox) Extend(other BBox) BBox {
+	return BBox{
+		MinLon: min(b.MinLon, other.MinLon),
+		MinLat: min(b.MinLat, other.MinLat),
+		MaxLon: max(b.MaxLon, other.MaxLon),
+		MaxLat: max(b.MaxLat, other.MaxLat),
+	}
+}
+
+// Overlaps reports whether the boxes share at least one position
+func (b BBox) Overlaps(other BBox) bool {
+	return b.MinLon <= other.MaxLon && other.MinLon <= b.MaxLon &&
+		b.MinLat <= other.MaxLat && other.MinLat <= b.MaxLat
+}
+
+// Point is a single position
+type Point struct {
+	Position
+}
+
+// Validate checks the coordinate ranges
+func (p Point) Validate() error {
+	return p.Position.Validate()
+}
+
+// BBox returns a degenerate box around the point
+func (p Point) BBox() BBox {
+	return BBox{MinLon: p.Lon, MinLat: p.Lat, MaxLon: p.Lon, MaxLat: p.Lat}
+}
+
+// Centroid returns the point itself
+func (p Point) Centroid() Position {
+	return p.Position
+}
+
+// Ring is a closed linear ring, its last position repeats the first
+type Ring []Position
+
+// Validate checks the coordinate ranges and that the ring is closed
+func (r Ring) Validate() error {
+	if len(r) < 4 {
+		return fmt.Errorf("ring needs at least 4 positions, got %d", len(r))
+	}
+	for _, p := range r {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	if r[0] != r[len(r)-1] {
+		return fmt.Errorf("ring is not closed: first position %v differs from last %v", r[0], r[len(r)-1])
+	}
+	return nil
+}
+
+// BBox returns the bounding box of the ring
+func (r Ring) BBox() BBox {
+	box := BBox{MinLon: r[0].Lon, MinLat: r[0].Lat, MaxLon: r[0].Lon, MaxLat: r[0].Lat}
+	for _, p := range r[1:] {
+		box = box.Extend(BBox{MinLon: p.Lon, MinLat: p.Lat, MaxLon: p.Lon, MaxLat: p.Lat})
+	}
+	return box
+}
+
+// centroid returns the area-weighted centroid of the ring and its signed
+// area, using the shoelace formula. Degenerate rings fall back to the
+// average of their vertices.
+func (r Ring) centroid() (Position, float64) {
+	var area, cx, cy float64
+	for i := 0; i < len(r)-1; i++ {
+		cross := r[i].Lon*r[i+1].Lat - r[i+1].Lon*r[i].Lat
+		area += cross
+		cx += (r[i].Lon + r[i+1].Lon) * cross
+		cy += (r[i].Lat + r[i+1].Lat) * cross
+	}
+	area /= 2
+
+	if area == 0 {
+		var sum Position
+		vertices := r[:len(r)-1]
+		for _, p := range vertices {
+			sum.Lon += p.Lon
+			sum.Lat += p.Lat
+		}
+		n := float64(len(vertices))
+		return Position{Lon: sum.Lon / n, Lat: sum.Lat / n}, 0
+	}
+
+	return Position{Lon: cx / (6 * area), Lat: cy / (6 * area)}, area
+}
+
+// Polygon is an outer ring followed by optional holes
+type Polygon []Ring
+
+// Validate checks every ring
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("polygon has no rings")
+	}
+	for i, ring := range p {
+		if err := ring.Validate(); err != nil {
+			return fmt.Errorf("ring %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// BBox returns the bounding box of the outer ring
+func (p Polygon) BBox() BBox {
+	return p[0].BBox()
+}
+
+// Centroid returns the centroid of the outer ring
+func (p Polygon) Centroid() Position {
+	centroid, _ := p[0].centroid()
+	return centroid
+}
+
+// MultiPolygon is a set of polygons
+type MultiPolygon []Polygon
+
+// Validate checks every polygon
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("multipolygon has no polygons")
+	}
+	for i, polygon := range m {
+		if err := polygon.Validate(); err != nil {
+			return fmt.Errorf("polygon %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// BBox returns the bounding box of all polygons
+func (m MultiPolygon) BBox() BBox {
+	box := m[0].BBox()
+	for _, polygon := range m[1:] {
+		box = box.Extend(polygon.BBox())
+	}
+	return box
+}
+
+// Centroid returns the area-weighted centroid of the outer rings
+func (m MultiPolygon) Centroid() Position {
+	var total float64
+	var sum Position
+	for _, polygon := range m {
+		centroid, area := polygon[0].centroid()
+		area = math.Abs(area)
+		total += area
+		sum.Lon += centroid.Lon * area
+		sum.Lat += centroid.Lat * area
+	}
+
+	if total == 0 {
+		return m[0].Centroid()
+	}
+	return Position{Lon: sum.Lon / total, Lat: sum.Lat / total}
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeometry_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Coordinates
+		wantErr string
+	}{
+		{
+			name: "point with altitude",
+			json: `{"date":"2025-01-20T00:00:00Z","type":"Point","coordinates":[-122.5,37.75,12]}`,
+			want: Point{Position{Lon: -122.5, Lat: 37.75}},
+		},
+		{
+			name: "polygon",
+			json: `{"date":"2025-01-20T00:00:00Z","type":"Polygon","coordinates":[[[0,0],[4,0],[4,2],[0,2],[0,0]]]}`,
+			want: Polygon{{{0, 0}, {4, 0}, {4, 2}, {0, 2}, {0, 0}}},
+		},
+		{
+			name: "multipolygon",
+			json: `{"date":"2025-01-20T00:00:00Z","type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+			want: MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+		},
+		{
+			name:    "unclosed ring",
+			json:    `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,2],[0,2]]]}`,
+			wantErr: "not closed",
+		},
+		{
+			name:    "latitude out of range",
+			json:    `{"type":"Point","coordinates":[10,95]}`,
+			wantErr: "latitude",
+		},
+		{
+			name:    "missing latitude",
+			json:    `{"type":"Point","coordinates":[10]}`,
+			wantErr: "longitude and latitude",
+		},
+		{
+			name:    "unsupported type",
+			json:    `{"type":"LineString","coordinates":[[0,0],[1,1]]}`,
+			wantErr: "unsupported geometry type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g Geometry
+			err := json.Unmarshal([]byte(tt.json), &g)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			gotJSON, _ := json.Marshal(g.Coordinates)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Coordinates = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestGeometry_UnmarshalMagnitude(t *testing.T) {
+	data := `{"magnitudeValue":65.00,"magnitudeUnit":"kts","magnitudeDescription":"Maximum Sustained Wind Speed",` +
+		`"date":"2024-09-25T12:00:00Z","type":"Point","coordinates":[-84.5,20.1]}`
+
+	var g Geometry
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if g.MagnitudeValue == nil || *g.MagnitudeValue != 65 {
+		t.Errorf("MagnitudeValue = %v, want 65", g.MagnitudeValue)
+	}
+	if g.MagnitudeUnit == nil || *g.MagnitudeUnit != "kts" {
+		t.Errorf("MagnitudeUnit = %v, want kts", g.MagnitudeUnit)
+	}
+	if g.MagnitudeDescription == nil || *g.MagnitudeDescription != "Maximum Sustained Wind Speed" {
+		t.Errorf("MagnitudeDescription = %v", g.MagnitudeDescription)
+	}
+
+	var noMagnitude Geometry
+	if err := json.Unmarshal([]byte(`{"magnitudeValue":null,"type":"Point","coordinates":[1,2]}`), &noMagnitude); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if noMagnitude.MagnitudeValue != nil || noMagnitude.MagnitudeUnit != nil {
+		t.Errorf("expected no magnitude, got %+v", noMagnitude)
+	}
+}
+
+func TestCoordinates_BBoxAndCentroid(t *testing.T) {
+	square := Polygon{{{0, 0}, {4, 0}, {4, 2}, {0, 2}, {0, 0}}}
+	// Vertices cluster on one side, the vertex average would be off center
+	skewed := Polygon{{{0, 0}, {4, 0}, {4, 2}, {3.9, 2}, {3.8, 2}, {0, 2}, {0, 0}}}
+	small := Polygon{{{10, 10}, {12, 10}, {12, 12}, {10, 12}, {10, 10}}}
+
+	tests := []struct {
+		name         string
+		coordinates  Coordinates
+		wantBBox     BBox
+		wantCentroid Position
+	}{
+		{
+			name:         "point",
+			coordinates:  Point{Position{Lon: 5, Lat: 6}},
+			wantBBox:     BBox{MinLon: 5, MinLat: 6, MaxLon: 5, MaxLat: 6},
+			wantCentroid: Position{Lon: 5, Lat: 6},
+		},
+		{
+			name:         "polygon",
+			coordinates:  skewed,
+			wantBBox:     BBox{MinLon: 0, MinLat: 0, MaxLon: 4, MaxLat: 2},
+			wantCentroid: Position{Lon: 2, Lat: 1},
+		},
+		{
+			name:         "multipolygon is area weighted",
+			coordinates:  MultiPolygon{square, small},
+			wantBBox:     BBox{MinLon: 0, MinLat: 0, MaxLon: 12, MaxLat: 12},
+			wantCentroid: Position{Lon: (2*8 + 11*4) / 12.0, Lat: (1*8 + 11*4) / 12.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coordinates.BBox(); got != tt.wantBBox {
+				t.Errorf("BBox() = %+v, want %+v", got, tt.wantBBox)
+			}
+			got := tt.coordinates.Centroid()
+			if math.Abs(got.Lon-tt.wantCentroid.Lon) > 1e-9 || math.Abs(got.Lat-tt.wantCentroid.Lat) > 1e-9 {
+				t.Errorf("Centroid() = %+v, want %+v", got, tt.wantCentroid)
+			}
+		})
+	}
+}
+
+func TestEvent_Observations(t *testing.T) {
+	event := Event{Geometry: []Geometry{
+		{Date: time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC), Type: GeometryPoint, Coordinates: Point{Position{Lon: 1, Lat: 1}}},
+		{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), Type: GeometryPoint, Coordinates: Point{Position{Lon: -3, Lat: 2}}},
+		{Date: time.Date(2025, 1, 22, 0, 0, 0, 0, time.UTC), Type: GeometryPoint, Coordinates: Point{Position{Lon: 0, Lat: 5}}},
+	}}
+
+	if first := event.FirstObservation(); first == nil || first.Date.Day() != 20 {
+		t.Errorf("FirstObservation() = %+v, want the 2025-01-20 geometry", first)
+	}
+	if last := event.LastObservation(); last == nil || last.Date.Day() != 22 {
+		t.Errorf("LastObservation() = %+v, want the 2025-01-22 geometry", last)
+	}
+	if box, ok := event.BBox(); !ok || box != (BBox{MinLon: -3, MinLat: 1, MaxLon: 1, MaxLat: 5}) {
+		t.Errorf("BBox() = %+v, %v", box, ok)
+	}
+
+	var empty Event
+	if empty.FirstObservation() != nil || empty.LastObservation() != nil {
+		t.Error("observations of an event without geometry should be nil")
+	}
+	if _, ok := empty.BBox(); ok {
+		t.Error("BBox() of an event without geometry should not be ok")
+	}
+}
+
+func TestEONETResponse_SkipsInvalidEvents(t *testing.T) {
+	// EONET_2 has no valid observation, EONET_4 keeps its valid ones
+	data := `{"title":"EONET Events","events":[
+		{"id":"EONET_1","geometry":[{"type":"Point","coordinates":[1,2]}]},
+		{"id":"EONET_2","geometry":[{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1]]]}]},
+		{"id":"EONET_3","geometry":[]},
+		{"id":"EONET_4","title":"Wildfire","categories":[{"id":"wildfires"}],"geometry":[
+			{"type":"Point","coordinates":[1,2]},
+			{"type":"Point","coordinates":[200,2]},
+			{"type":"Line","coordinates":[[1,2],[3,4]]},
+			{"type":"Point","coordinates":[3,4]}
+		]}
+	]}`
+
+	var response EONETResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if response.Title != "EONET Events" {
+		t.Errorf("Title = %q", response.Title)
+	}
+	if len(response.Events) != 3 || response.Events[0].ID != "EONET_1" || response.Events[1].ID != "EONET_3" || response.Events[2].ID != "EONET_4" {
+		t.Fatalf("Events = %+v, want EONET_1, EONET_3 and EONET_4", response.Events)
+	}
+	if len(response.InvalidEvents) != 1 || !strings.Contains(response.InvalidEvents[0].Error(), "EONET_2") {
+		t.Errorf("InvalidEvents = %v, want the EONET_2 error", response.InvalidEvents)
+	}
+
+	partial := response.Events[2]
+	if partial.Title != "Wildfire" || len(partial.Categories) != 1 || len(partial.Geometry) != 2 {
+		t.Errorf("EONET_4 = %+v, want its title, category and two valid geometries", partial)
+	}
+	var invalidErr *InvalidGeometryError
+	if len(partial.InvalidGeometries) != 2 || !errors.As(partial.InvalidGeometries[0], &invalidErr) || invalidErr.Index != 1 {
+		t.Errorf("EONET_4 InvalidGeometries = %v, want geometries 1 and 2", partial.InvalidGeometries)
+	}
+	if len(response.Events[0].InvalidGeometries) != 0 {
+		t.Errorf("EONET_1 InvalidGeometries = %v, want none", response.Events[0].InvalidGeometries)
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	box, err := ParseBBox("-125, 32,-114,42.5")
+	if err != nil || box != (BBox{MinLon: -125, MinLat: 32, MaxLon: -114, MaxLat: 42.5}) {
+		t.Errorf("ParseBBox() = %+v, %v", box, err)
+	}
+
+	for _, value := range []string{"", "1,2,3", "a,0,1,1", "-181,0,0,1", "0,-91,1,1", "10,0,0,1", "0,10,1,0"} {
+		if _, err := ParseBBox(value); err == nil {
+			t.Errorf("ParseBBox(%q) expected error", value)
+		}
+	}
+}
+
+func TestBBox_Overlaps(t *testing.T) {
+	box := BBox{MinLon: 0, MinLat: 0, MaxLon: 10, MaxLat: 10}
+
+	tests := []struct {
+		other BBox
+		want  bool
+	}{
+		{BBox{MinLon: 5, MinLat: 5, MaxLon: 25, MaxLat: 25}, true},
+		{BBox{MinLon: 10, MinLat: 10, MaxLon: 12, MaxLat: 12}, true},
+		{BBox{MinLon: -5, MinLat: -5, MaxLon: 15, MaxLat: 15}, true},
+		{BBox{MinLon: 11, MinLat: 0, MaxLon: 20, MaxLat: 10}, false},
+		{BBox{MinLon: 0, MinLat: -10, MaxLon: 10, MaxLat: -1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := box.Overlaps(tt.other); got != tt.want {
+			t.Errorf("Overlaps(%+v) = %v, want %v", tt.other, got, tt.want)
+		}
+	}
+}